@@ -0,0 +1,49 @@
+package genembed
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// compressMethods list of supported compression methods.
+var compressMethods = map[string]func(w io.Writer) (io.WriteCloser, error){
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	},
+	"flate": func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flate.BestCompression)
+	},
+	"zlib": func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, zlib.BestCompression)
+	},
+}
+
+// compress returns the data compressed with the method.
+// If the method is empty the data is returned as is.
+func compress(method string, dat []byte) ([]byte, error) {
+	if method == "" {
+		return dat, nil
+	}
+
+	newWriter, ok := compressMethods[method]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression method %q", method)
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := newWriter(buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(dat); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package genembed
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// constName returns the name of the constant with the key of the embedded file:
+// the name of the variable, "Key" and the words of the key with uppercase first letters.
+// The words are the sequences of letters and digits, for example "css/app.min.css" is <Var>KeyCssAppMinCss.
+func constName(fieldName, key string) string {
+	var b strings.Builder
+	b.WriteString(fieldName + "Key")
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	if len(words) == 0 {
+		b.WriteString("File")
+	}
+	return b.String()
+}
+
+// constNames returns the names of the constants by the keys.
+// The keys with the same name get the numeric suffix starting from 2 in the sorted order of the keys,
+// so the names depend only on the set of the keys.
+func constNames(fieldName string, keys []string) map[string]string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	base := make(map[string]string, len(sorted))
+	count := map[string]int{}
+	for _, key := range sorted {
+		base[key] = constName(fieldName, key)
+		count[base[key]]++
+	}
+
+	names := make(map[string]string, len(sorted))
+	used := make(map[string]bool, len(sorted))
+	for _, key := range sorted {
+		name := base[key]
+		// NOTE: the suffixed name is not the name of any key, so it does not take the name of the next keys
+		if used[name] {
+			for i := 2; ; i++ {
+				suffixed := name + strconv.Itoa(i)
+				if !used[suffixed] && count[suffixed] == 0 {
+					name = suffixed
+					break
+				}
+			}
+		}
+		used[name] = true
+		names[key] = name
+	}
+	return names
+}
+
+// constEntries returns the constants with the keys of the files between the patterns of the const block.
+// The constants are not aligned, the block is formatted with the skeleton of the file.
+func constEntries(fieldName string, keys []string) []entry {
+	names := constNames(fieldName, keys)
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	entries := make([]entry, len(sorted))
+	for i, key := range sorted {
+		entries[i] = entry{names[key], []byte("\t" + names[key] + " = " + strconv.Quote(key) + "\n")}
+	}
+	return entries
+}
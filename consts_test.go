@@ -0,0 +1,43 @@
+package genembed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_constNames(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want map[string]string
+	}{
+		{"words", []string{"index.html", "css/app.min.css", "404.html", "Über uns.txt"}, map[string]string{
+			"index.html":      "AKeyIndexHtml",
+			"css/app.min.css": "AKeyCssAppMinCss",
+			"404.html":        "AKey404Html",
+			"Über uns.txt":    "AKeyÜberUnsTxt",
+		}},
+		{"noWords", []string{"...", "-"}, map[string]string{
+			"-":   "AKeyFile",
+			"...": "AKeyFile2",
+		}},
+		{"collisions", []string{"a_b", "a-b", "a.b", "ab2", "AB"}, map[string]string{
+			"AB":  "AKeyAB",
+			"a-b": "AKeyAB2",
+			"a.b": "AKeyAB3",
+			"a_b": "AKeyAB4",
+			"ab2": "AKeyAb2",
+		}},
+		{"suffixTaken", []string{"a b", "a.b", "a b 2"}, map[string]string{
+			"a b":   "AKeyAB",
+			"a b 2": "AKeyAB2",
+			"a.b":   "AKeyAB3",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, constNames("A", tt.keys))
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package genembed
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data of the custom template of the generated file, see Options.Template.
+type TemplateData struct {
+	Package  string          // package name of the generated file
+	Var      string          // name of the variable with embedded files
+	Compress string          // compression method of the files, empty if the files are not compressed
+	Entries  []TemplateEntry // embedded files sorted by the name
+}
+
+// TemplateEntry is the embedded file in the custom template.
+type TemplateEntry struct {
+	Name     string      // key of the file
+	Bytes    []byte      // content of the file, compressed if the compression method is set
+	Size     int64       // size of the uncompressed content
+	Hash     string      // hex encoded SHA-256 digest of the uncompressed content
+	Mode     os.FileMode // mode of the file
+	ModTime  time.Time   // modification time of the file
+	Encoding string      // encoding of the file in the generated code: bytes or string
+}
+
+// TemplateFuncs are the functions available in the custom template:
+//
+//	bytes       composite literal []byte{0x31, ...} of the []byte
+//	string      string literal of the []byte, the raw string literal is used for the multiline text
+//	literal     []byte literal of the content of the TemplateEntry in its encoding
+//	quote       interpreted string literal of the string
+//	base64      standard base64 encoding of the []byte
+//	unexported  the name with lowercase first letter
+var TemplateFuncs = template.FuncMap{
+	"bytes":      bytesLiteral,
+	"string":     stringLiteral,
+	"literal":    entryLiteral,
+	"quote":      strconv.Quote,
+	"base64":     base64.StdEncoding.EncodeToString,
+	"unexported": unexported,
+}
+
+// templatePrefix is the prefix of the pattern at the end of the file generated by the custom template,
+// the pattern contains the name of the variable, see templatePattern.
+const templatePrefix = "// [TEMPLATE "
+
+// templatePattern returns the pattern of the file generated by the custom template of the variable.
+func templatePattern(fieldName string) string {
+	return templatePrefix + fieldName + "]"
+}
+
+// parseTemplate returns the custom template with TemplateFuncs.
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("custom").Funcs(TemplateFuncs).Parse(text)
+}
+
+// executeTemplate returns the generated file by the custom template formatted with gofmt.
+// The src is the existing generated file, it must be empty or generated by the custom template of the variable,
+// because the whole file is replaced.
+func (g *Generator) executeTemplate(src []byte) ([]byte, error) {
+	pattern := templatePattern(g.opts.Var)
+	if len(bytes.TrimSpace(src)) > 0 && !bytes.Contains(src, []byte("\n"+pattern+"\n")) {
+		return nil, fmt.Errorf("the file is not generated by the custom template of %s, the variable with the custom template needs own output file", g.opts.Var)
+	}
+
+	data := TemplateData{
+		Package:  g.opts.Package,
+		Var:      g.opts.Var,
+		Compress: g.opts.Compress,
+		Entries:  make([]TemplateEntry, 0, len(g.files)),
+	}
+	// NOTE: the file added later replaces the file with the same name
+	index := map[string]int{}
+	for _, e := range g.files {
+		if i, ok := index[e.Name]; ok {
+			data.Entries[i] = e
+			continue
+		}
+		index[e.Name] = len(data.Entries)
+		data.Entries = append(data.Entries, e)
+	}
+	sort.Slice(data.Entries, func(i, j int) bool { return data.Entries[i].Name < data.Entries[j].Name })
+
+	var buf bytes.Buffer
+	if err := g.tpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed execute template: %v", err)
+	}
+	buf.WriteString("\n" + pattern + "\n")
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed formatting: %v", err)
+	}
+	return out, nil
+}
+
+// bytesLiteral returns the composite literal of the data.
+func bytesLiteral(dat []byte) string {
+	var buf bytes.Buffer
+	buf.Grow(len(dat)*6 + 16)
+	buf.WriteString("[]byte{")
+	if len(dat) > 0 {
+		buf.WriteString("\n")
+	}
+	for len(dat) > 0 {
+		row := dat
+		if len(row) > 20 {
+			row = row[:20]
+		}
+		buf.WriteString(bytesDump(row) + "\n")
+		dat = dat[len(row):]
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// stringLiteral returns the string literal of the data.
+func stringLiteral(dat []byte) string {
+	if isRawString(dat) && bytes.Contains(dat, []byte("\n")) {
+		return "`" + string(dat) + "`"
+	}
+	return strconv.Quote(string(dat))
+}
+
+// entryLiteral returns the []byte literal of the content of the entry in the encoding of the entry.
+func entryLiteral(e TemplateEntry) string {
+	if e.Encoding == EncodingString {
+		return "[]byte(" + stringLiteral(e.Bytes) + ")"
+	}
+	return bytesLiteral(e.Bytes)
+}
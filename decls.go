@@ -0,0 +1,204 @@
+package genembed
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+	"text/template"
+)
+
+// varSections are the sections of the variable in the generated files.
+var varSections = []string{"embeddedFiles", "embeddedMetadata", "embeddedConsts", "embeddedPaths"}
+
+// declareVar returns the skeleton with the declarations of the variable executed by the template with the config.
+// The declarations are added to the end of the skeleton if the variable is not declared, the header is added
+// if the skeleton is empty. The declarations generated with other options (compression, map name, functions)
+// are replaced by the declarations with the current options and the sections of the variable are removed
+// from the sections, because the existing entries may be encoded by the other options.
+func declareVar(skeleton []byte, sections map[string][]entry, header, tpl *template.Template, cfg embeddedFileConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, cfg); err != nil {
+		return nil, fmt.Errorf("failed write tpl to file: %v", err)
+	}
+	decl := buf.Bytes()
+
+	if len(skeleton) == 0 {
+		var out bytes.Buffer
+		if err := header.Execute(&out, cfg); err != nil {
+			return nil, fmt.Errorf("failed write tpl to file: %v", err)
+		}
+		out.Write(decl)
+		return out.Bytes(), nil
+	}
+
+	names, err := varNames(tpl, cfg)
+	if err != nil {
+		return nil, err
+	}
+	want, err := printDecls(append([]byte("package p\n"), decl...), cfg.FieldName, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed parse tpl of the variable: %v", err)
+	}
+	got, err := printDecls(skeleton, cfg.FieldName, names)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(got) == 0 {
+		// NOTE: the skeleton is copied to not modify the array of the caller
+		return append(append([]byte(nil), skeleton...), decl...), nil
+	}
+	if equalDecls(got, want) {
+		return skeleton, nil
+	}
+
+	out := make([]byte, 0, len(skeleton)+len(decl))
+	last := 0
+	for i, d := range got {
+		out = append(out, skeleton[last:d.start]...)
+		if i == 0 {
+			out = append(out, decl...)
+		}
+		last = d.end
+	}
+	out = append(out, skeleton[last:]...)
+
+	for _, section := range varSections {
+		delete(sections, section+" "+cfg.FieldName)
+	}
+	return out, nil
+}
+
+// printedDecl is the declaration of the variable printed without comments.
+type printedDecl struct {
+	src        string
+	start, end int // offsets of the declaration with the doc comment and the line break in the source code
+}
+
+func equalDecls(got, want []printedDecl) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].src != want[i].src {
+			return false
+		}
+	}
+	return true
+}
+
+// printDecls returns the declarations of the variable in the source code: the declarations with the names
+// generated for the variable and the declarations containing the patterns of the sections of the variable.
+func printDecls(src []byte, fieldName string, names map[string]bool) ([]printedDecl, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	file := fset.File(f.Pos())
+
+	var res []printedDecl
+	for _, decl := range f.Decls {
+		if !names[declName(decl)] && !hasVarPattern(f, decl, fieldName) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+		if err := cfg.Fprint(&buf, fset, decl); err != nil {
+			return nil, err
+		}
+
+		pos := decl.Pos()
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Doc != nil {
+				pos = decl.Doc.Pos()
+			}
+		case *ast.FuncDecl:
+			if decl.Doc != nil {
+				pos = decl.Doc.Pos()
+			}
+		}
+		start := bytes.LastIndexByte(src[:file.Offset(pos)], '\n') + 1
+		end := file.Offset(decl.End())
+		if end < len(src) && src[end] == '\n' {
+			end++
+		}
+		res = append(res, printedDecl{buf.String(), start, end})
+	}
+	return res, nil
+}
+
+// declName returns the name of the declaration: the first declared name or the type of the receiver of the method.
+func declName(decl ast.Decl) string {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv == nil || len(decl.Recv.List) == 0 {
+			return decl.Name.Name
+		}
+		typ := decl.Recv.List[0].Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		if ident, ok := typ.(*ast.Ident); ok {
+			return ident.Name
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.ValueSpec:
+				return spec.Names[0].Name
+			case *ast.TypeSpec:
+				return spec.Name.Name
+			}
+		}
+	}
+	return ""
+}
+
+// hasVarPattern reports whether the declaration contains the pattern of the section of the variable.
+func hasVarPattern(f *ast.File, decl ast.Decl, fieldName string) bool {
+	for _, group := range f.Comments {
+		if group.Pos() < decl.Pos() || group.End() > decl.End() {
+			continue
+		}
+		for _, c := range group.List {
+			m := patternRe.FindStringSubmatch(c.Text)
+			if m == nil {
+				continue
+			}
+			if fields := strings.Fields(m[2]); len(fields) == 2 && fields[1] == fieldName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// varNames returns the names of all the declarations the template may generate for the variable with any options.
+func varNames(tpl *template.Template, cfg embeddedFileConfig) (map[string]bool, error) {
+	names := map[string]bool{}
+	for _, immutable := range []bool{false, true} {
+		all := cfg
+		all.Compress, all.FS, all.Metadata, all.Accessors, all.Immutable, all.Consts = "gzip", true, true, true, immutable, true
+
+		buf := bytes.NewBufferString("package p\n")
+		if err := tpl.Execute(buf, all); err != nil {
+			return nil, fmt.Errorf("failed write tpl to file: %v", err)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), "", buf.Bytes(), 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed parse tpl of the variable: %v", err)
+		}
+		for _, decl := range f.Decls {
+			names[declName(decl)] = true
+		}
+	}
+	delete(names, "")
+	return names, nil
+}
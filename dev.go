@@ -0,0 +1,267 @@
+package genembed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DevBuildTag is the build tag of the development file.
+// The development file reads the embedded files from the disk, so the changes of the files
+// are visible without the generation:
+//
+//	go run -tags genembed_dev .
+const DevBuildTag = "genembed_dev"
+
+const (
+	prodBuildConstraint = "//go:build !" + DevBuildTag + "\n// +build !" + DevBuildTag
+	devBuildConstraint  = "//go:build " + DevBuildTag + "\n// +build " + DevBuildTag
+)
+
+// WriteDevTo writes the development file with the variable to w.
+func (g *Generator) WriteDevTo(w io.Writer) (int64, error) {
+	out, err := g.UpdateDev(nil)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(out)
+	return int64(n), err
+}
+
+// UpdateDev returns the src of the development file with the variable.
+// The variable of the development file has the same API as the generated one,
+// but the content of the files is read from the paths of the entries.
+func (g *Generator) UpdateDev(src []byte) ([]byte, error) {
+	if !g.opts.Dev {
+		return nil, errors.New("development mode is disabled")
+	}
+
+	skeleton, sections, err := splitSections(src, g.sectionDecls())
+	if err != nil {
+		return nil, fmt.Errorf("failed parse generated file: %v", err)
+	}
+
+	cfg := g.fileConfig()
+	cfg.DevFile = true
+	skeleton, err = declareVar(skeleton, sections, embeddedDevFileTpl, embeddedDevVarTpl, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "embeddedPaths " + g.opts.Var
+	sections[name] = mergeEntries(sections[name], g.paths, g.removed)
+	if g.opts.Consts {
+		sections["embeddedConsts "+g.opts.Var] = g.consts(sections[name])
+	}
+
+	// NOTE: the development file contains only the paths of the files, so the whole file is formatted
+	return gofmt(joinSections(skeleton, sections))
+}
+
+// checkDevMode returns an error if the generated file contains other variables generated
+// in the other mode than the variable. The build constraint excludes all variables of the file
+// in the development mode, so the variables without the development file become undefined.
+func (g *Generator) checkDevMode(skeleton []byte, sections map[string][]entry) error {
+	if bytes.Contains(skeleton, []byte(prodBuildConstraint)) == g.opts.Dev {
+		return nil
+	}
+	var others []string
+	for name := range sections {
+		if strings.HasPrefix(name, "embeddedFiles ") && name != "embeddedFiles "+g.opts.Var {
+			others = append(others, strings.TrimPrefix(name, "embeddedFiles "))
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	sort.Strings(others)
+	if g.opts.Dev {
+		return fmt.Errorf("the file contains the variables %s generated without the development mode, the variable %s in the development mode needs own output file",
+			strings.Join(others, ", "), g.opts.Var)
+	}
+	return fmt.Errorf("the file contains the variables %s generated in the development mode, the variable %s without the development mode needs own output file",
+		strings.Join(others, ", "), g.opts.Var)
+}
+
+// addBuildConstraint returns the src with the build constraint after the first line of the generated file.
+func addBuildConstraint(src []byte, constraint string) []byte {
+	if bytes.Contains(src, []byte(constraint)) {
+		return src
+	}
+	i := bytes.IndexByte(src, '\n') + 1
+	out := make([]byte, 0, len(src)+len(constraint)+3)
+	out = append(out, src[:i]...)
+	out = append(out, "\n"+constraint+"\n\n"...)
+	return append(out, src[i:]...)
+}
+
+// pathEntry returns the formatted entry of the table of the paths of the files.
+func pathEntry(key, path string) []byte {
+	return []byte("\t" + strconv.Quote(key) + ": " + strconv.Quote(path) + ",\n")
+}
+
+var embeddedDevFileTpl = template.Must(template.New("_genembed_dev.go").Parse(`// Code generated by github.com/gebv/go-embed. DO NOT EDIT.
+
+` + devBuildConstraint + `
+
+package {{.Package}}
+`))
+
+// embeddedDevVarTpl is the template of the variable in the development file.
+var embeddedDevVarTpl = template.Must(template.New("devVar").Funcs(template.FuncMap{
+	"startPattern": startPattern,
+	"endPattern":   endPattern,
+	"unexported":   unexported,
+}).Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+// {{$map}} list of embedded files read from the disk on start (development mode).
+{{- if .Compress }}
+// The files are compressed with {{.Compress}}, use {{$name}}Get to get the current content.
+{{- end }}
+var {{$map}} = {{$private}}Load()
+
+// {{$private}}Paths slash-separated paths of the embedded files,
+// the relative paths are resolved from the directory of this file.
+var {{$private}}Paths = map[string]string{
+	{{startPattern "embeddedPaths" $name}}
+	{{endPattern "embeddedPaths" $name}}
+}
+{{- if .Consts }}
+{{ template "consts" . }}
+{{- end }}
+
+// {{$private}}Path returns the path of the embedded file on the disk.
+func {{$private}}Path(name string) (string, error) {
+	filename, ok := {{$private}}Paths[name]
+	if !ok {
+		return "", errors.New("not found embedded file " + name)
+	}
+	filename = filepath.FromSlash(filename)
+	if !filepath.IsAbs(filename) {
+		_, file, _, _ := runtime.Caller(0)
+		filename = filepath.Join(filepath.Dir(file), filename)
+	}
+	return filename, nil
+}
+
+// {{$private}}Read returns the current content of the embedded file from the disk.
+func {{$private}}Read(name string) ([]byte, error) {
+	filename, err := {{$private}}Path(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filename)
+}
+
+func {{$private}}Load() map[string][]byte {
+	files := make(map[string][]byte, len({{$private}}Paths))
+	for name := range {{$private}}Paths {
+		dat, err := {{$private}}Read(name)
+		{{- if .Compress }}
+		if err == nil {
+			dat, err = {{$private}}Compress(dat)
+		}
+		{{- end }}
+		if err != nil {
+			panic("genembed: " + err.Error())
+		}
+		files[name] = dat
+	}
+	return files
+}
+{{- if .Metadata }}
+{{ template "devMetadata" . }}
+{{- end }}
+{{- if .Compress }}
+{{ template "devCompress" . }}
+{{- end }}
+{{- if .Accessors }}
+{{ template "accessors" . }}
+{{- end }}
+{{- if .FS }}
+{{ template "fs" . }}
+{{- end }}
+`))
+
+var _ = template.Must(embeddedDevVarTpl.AddParseTree("consts", constsTpl.Tree))
+
+var _ = template.Must(embeddedDevVarTpl.AddParseTree("metadataType", metadataTypeTpl.Tree))
+
+var _ = template.Must(embeddedDevVarTpl.AddParseTree("accessors", accessorsTpl.Tree))
+
+var _ = template.Must(embeddedDevVarTpl.AddParseTree("fs", fsTpl.Tree))
+
+var _ = template.Must(embeddedDevVarTpl.New("devMetadata").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+{{- template "metadataType" . }}
+
+// {{$name}}Info returns the current metadata of the embedded file from the disk.
+func {{$name}}Info(name string) ({{$name}}Metadata, bool) {
+	filename, err := {{$private}}Path(name)
+	if err != nil {
+		return {{$name}}Metadata{}, false
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return {{$name}}Metadata{}, false
+	}
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return {{$name}}Metadata{}, false
+	}
+	sum := sha256.Sum256(dat)
+	return {{$name}}Metadata{Size: int64(len(dat)), Mode: info.Mode(), ModTime: info.ModTime(), SHA256: hex.EncodeToString(sum[:])}, true
+}
+`))
+
+var _ = template.Must(embeddedDevVarTpl.New("devCompress").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+// {{$name}}Get returns the current content of the embedded file from the disk.
+func {{$name}}Get(name string) ([]byte, error) {
+	{{- if .Accessors }}
+	if _, ok := {{$private}}Paths[name]; !ok {
+		return nil, &{{$name}}NotFoundError{Name: name}
+	}
+	{{- end }}
+	return {{$private}}Read(name)
+}
+
+// {{$name}}Compressed returns the current content of the embedded file from the disk compressed with {{.Compress}}.
+func {{$name}}Compressed(name string) ([]byte, bool) {
+	dat, err := {{$private}}Read(name)
+	if err == nil {
+		dat, err = {{$private}}Compress(dat)
+	}
+	return dat, err == nil
+}
+
+func {{$private}}Compress(dat []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	{{- if eq .Compress "flate" }}
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	{{- else }}
+	w, err := {{.Compress}}.NewWriterLevel(&buf, {{.Compress}}.BestCompression)
+	{{- end }}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(dat); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+`))
@@ -0,0 +1,123 @@
+package genembed
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorDev(t *testing.T) {
+	g, err := NewGenerator(Options{Package: "a", Var: "A"})
+	require.NoError(t, err)
+	_, err = g.UpdateDev(nil)
+	require.EqualError(t, err, "development mode is disabled")
+
+	// the variable is generated without development mode
+	require.NoError(t, g.Add("f1", strings.NewReader("1")))
+	src, err := g.Update(nil)
+	require.NoError(t, err)
+	require.NotContains(t, string(src), DevBuildTag)
+
+	g, err = NewGenerator(Options{Package: "a", Var: "A", Dev: true})
+	require.NoError(t, err)
+	require.EqualError(t, g.Add("f1", strings.NewReader("1")), `empty path of the embedded file "f1" in development mode`)
+	require.NoError(t, g.AddEntry(Entry{Name: "f1", Data: []byte("1"), Path: "f1"}))
+	require.NoError(t, g.AddEntry(Entry{Name: "long/name", Data: []byte("2"), Path: "../assets/long/name"}))
+
+	// the build constraint is added to the existing file
+	src, err = g.Update(src)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(src), "// Code generated by github.com/gebv/go-embed. DO NOT EDIT.\n\n"+
+		"//go:build !genembed_dev\n// +build !genembed_dev\n\npackage a\n"), string(src))
+	again, err := g.Update(src)
+	require.NoError(t, err)
+	require.Equal(t, string(src), string(again))
+
+	var buf bytes.Buffer
+	_, err = g.WriteDevTo(&buf)
+	require.NoError(t, err)
+	dev := buf.String()
+	require.True(t, strings.HasPrefix(dev, "// Code generated by github.com/gebv/go-embed. DO NOT EDIT.\n\n"+
+		"//go:build genembed_dev\n// +build genembed_dev\n\npackage a\n"), dev)
+	require.Contains(t, dev, "\t\"f1\":        \"f1\",\n\t\"long/name\": \"../assets/long/name\",\n")
+
+	// the aligned entries are replaced
+	g, err = NewGenerator(Options{Package: "a", Var: "A", Dev: true})
+	require.NoError(t, err)
+	require.NoError(t, g.AddEntry(Entry{Name: "f1", Data: []byte("1"), Path: "f1.txt"}))
+	out, err := g.UpdateDev(buf.Bytes())
+	require.NoError(t, err)
+	require.Contains(t, string(out), "\t\"f1\":        \"f1.txt\",\n\t\"long/name\": \"../assets/long/name\",\n")
+	formatted, err := format.Source(out)
+	require.NoError(t, err)
+	require.Equal(t, string(formatted), string(out))
+}
+
+func TestGeneratorDevImmutable(t *testing.T) {
+	g, err := NewGenerator(Options{Package: "a", Var: "A", Dev: true})
+	require.NoError(t, err)
+	require.NoError(t, g.AddEntry(Entry{Name: "f1", Data: []byte("1"), Path: "f1"}))
+	require.NoError(t, g.AddEntry(Entry{Name: "f2", Data: []byte("2"), Path: "f2"}))
+	src, err := g.Update(nil)
+	require.NoError(t, err)
+	dev, err := g.UpdateDev(nil)
+	require.NoError(t, err)
+
+	// the exported variables are replaced by the unexported maps with the accessors
+	g, err = NewGenerator(Options{Package: "a", Var: "A", Immutable: true, Dev: true})
+	require.NoError(t, err)
+	require.NoError(t, g.AddEntry(Entry{Name: "f1", Data: []byte("1"), Path: "f1"}))
+	src, err = g.Update(src)
+	require.NoError(t, err)
+	dev, err = g.UpdateDev(dev)
+	require.NoError(t, err)
+
+	for _, out := range []string{string(src), string(dev)} {
+		require.NotContains(t, out, "var A ")
+		require.NotContains(t, out, `"f2"`)
+		require.Contains(t, out, "var aFiles = ")
+		require.Contains(t, out, "func AGet(name string) ([]byte, error) {")
+	}
+	require.Contains(t, string(src), "return aCopy(dat), nil")
+	require.Contains(t, string(dev), "var aPaths = map[string]string{\n\t// [START embeddedPaths A]\n\t\"f1\": \"f1\",\n")
+}
+
+func TestGeneratorDevMixed(t *testing.T) {
+	plain, err := NewGenerator(Options{Package: "a", Var: "Plain"})
+	require.NoError(t, err)
+	require.NoError(t, plain.Add("f1", strings.NewReader("1")))
+	dev, err := NewGenerator(Options{Package: "a", Var: "Dev", Dev: true})
+	require.NoError(t, err)
+	require.NoError(t, dev.AddEntry(Entry{Name: "f2", Data: []byte("2"), Path: "f2"}))
+
+	src, err := plain.Update(nil)
+	require.NoError(t, err)
+	_, err = dev.Update(src)
+	require.EqualError(t, err, "the file contains the variables Plain generated without the development mode, the variable Dev in the development mode needs own output file")
+
+	src, err = dev.Update(nil)
+	require.NoError(t, err)
+	_, err = plain.Update(src)
+	require.EqualError(t, err, "the file contains the variables Dev generated in the development mode, the variable Plain without the development mode needs own output file")
+
+	// the variables in the same mode share the file
+	other, err := NewGenerator(Options{Package: "a", Var: "Other", Dev: true})
+	require.NoError(t, err)
+	require.NoError(t, other.AddEntry(Entry{Name: "f3", Data: []byte("3"), Path: "f3"}))
+	src, err = other.Update(src)
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(src), prodBuildConstraint))
+
+	// the variable switched to the development mode is generated again
+	src, err = plain.Update(nil)
+	require.NoError(t, err)
+	plainDev, err := NewGenerator(Options{Package: "a", Var: "Plain", Dev: true})
+	require.NoError(t, err)
+	require.NoError(t, plainDev.AddEntry(Entry{Name: "f1", Data: []byte("1"), Path: "f1"}))
+	src, err = plainDev.Update(src)
+	require.NoError(t, err)
+	require.Contains(t, string(src), prodBuildConstraint)
+}
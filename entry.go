@@ -0,0 +1,84 @@
+package genembed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// embeddedEntry returns the formatted map entry with the (compressed) contents of the file.
+// The encoding "bytes" writes the contents as a composite literal, "string" as a string literal.
+func embeddedEntry(key string, dat []byte, encoding string) []byte {
+	buf := new(bytes.Buffer)
+
+	// NOTE: each entry takes several lines, so the formatting does not depend on the neighboring entries
+	// (gofmt aligns the values of the single-line entries)
+	if encoding == EncodingString || len(dat) == 0 {
+		if isRawString(dat) && bytes.Contains(dat, []byte("\n")) {
+			buf.WriteString("\t" + strconv.Quote(key) + ": []byte(`" + string(dat) + "`),\n")
+		} else {
+			buf.WriteString("\t" + strconv.Quote(key) + ": []byte(\n\t\t" + strconv.Quote(string(dat)) + ",\n\t),\n")
+		}
+		return buf.Bytes()
+	}
+
+	buf.Grow(len(dat)*6 + len(key) + 16)
+	buf.WriteString("\t" + strconv.Quote(key) + ": []byte{\n")
+
+	rowSize := 20
+	for len(dat) > 0 {
+		row := dat
+		if len(row) > rowSize {
+			row = row[:rowSize]
+		}
+		buf.WriteString("\t\t" + bytesDump(row) + "\n")
+		dat = dat[len(row):]
+	}
+
+	buf.WriteString("\t},\n")
+	return buf.Bytes()
+}
+
+// metadataEntry returns the formatted entry of the metadata table with size, mode, modification time and SHA-256 of the file.
+func metadataEntry(key string, dat []byte, mode os.FileMode, modTime time.Time) []byte {
+	sum := sha256.Sum256(dat)
+	// NOTE: the entry is formatted and takes several lines as the entries of the files
+	return []byte(fmt.Sprintf("\t%s: {\n\t\tSize: %d, Mode: %#o, ModTime: time.Unix(%d, %d), SHA256: %q,\n\t},\n",
+		strconv.Quote(key), len(dat), uint32(mode), modTime.Unix(), modTime.Nanosecond(), hex.EncodeToString(sum[:])))
+}
+
+// bytesDump returns the comma separated hex bytes with trailing comma.
+func bytesDump(in []byte) string {
+	const digits = "0123456789abcdef"
+	buf := make([]byte, 0, len(in)*6)
+	for i, b := range in {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, '0', 'x')
+		if b >= 0x10 {
+			buf = append(buf, digits[b>>4])
+		}
+		buf = append(buf, digits[b&0xf], ',')
+	}
+	return string(buf)
+}
+
+// isRawString reports whether the data can be written as a raw string literal.
+func isRawString(in []byte) bool {
+	// NOTE: the raw string can not contain backquotes, the carriage returns are discarded from the raw string,
+	// NUL and BOM are not allowed in the source code
+	return utf8.Valid(in) && !bytes.ContainsAny(in, "`\r\x00\ufeff")
+}
+
+// unexported returns the name with lowercase first letter.
+func unexported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,39 @@
+package genembed
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_isRawString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		raw  bool
+	}{
+		{"empty", "", true},
+		{"text", "abc\ndef\n", true},
+		{"unicode", "привет\tмир", true},
+		{"backquote", "a`b", false},
+		{"carriageReturn", "a\r\nb", false},
+		{"nul", "a\x00b", false},
+		{"bom", "\ufeffabc", false},
+		{"binary", "\x1f\x8b\x08\xff", false},
+		{"quotes", `"),` + "\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := isRawString([]byte(tt.in))
+			require.Equal(t, tt.raw, raw)
+			if !raw {
+				return
+			}
+
+			unquoted, err := strconv.Unquote("`" + tt.in + "`")
+			require.NoError(t, err)
+			require.Equal(t, tt.in, unquoted)
+		})
+	}
+}
@@ -9,10 +9,12 @@ import (
 func main() {
 	log.Println("file1", string(EmbedFiles["file1"]))
 	log.Println("file2", string(EmbedFiles["file2"]))
-	log.Println("not exists", string(EmbedFiles["not exists"]))
+	if _, err := EmbedFilesGet("not exists"); err != nil {
+		log.Println("not exists", err)
+	}
 	log.Println("file from pkg", string(somepkg.EmbedFiles["somefile"]))
 	log.Println("file3", string(EmbedFiles["file3"]))
 }
 
-//go:generate genembed EmbedFiles file1 file2
-//go:generate genembed EmbedFiles file3
+//go:generate genembed -accessors EmbedFiles file1 file2
+//go:generate genembed -accessors EmbedFiles file3
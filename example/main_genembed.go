@@ -1,9 +1,17 @@
 // Code generated by github.com/gebv/go-embed. DO NOT EDIT.
 package main
 
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
 // EmbedFiles list of embedded files.
 var EmbedFiles = map[string][]byte{
-	// [START embeddedFiles]
+	// [START embeddedFiles EmbedFiles]
 	"file1": []byte{
 		0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x30, 0xa,
 	},
@@ -15,5 +23,74 @@ var EmbedFiles = map[string][]byte{
 	"file3": []byte{
 		0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x66, 0x69, 0x6c, 0x65, 0x33, 0xa,
 	},
-	// [END embeddedFiles]
+	// [END embeddedFiles EmbedFiles]
+}
+
+// EmbedFilesNotFoundError is returned by the accessors of EmbedFiles if the file is not embedded.
+type EmbedFilesNotFoundError struct {
+	Name string
+}
+
+func (e *EmbedFilesNotFoundError) Error() string {
+	return "not found embedded file " + e.Name
+}
+
+// Is reports whether the target is os.ErrNotExist.
+func (e *EmbedFilesNotFoundError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+// EmbedFilesGet returns the content of the embedded file.
+func EmbedFilesGet(name string) ([]byte, error) {
+	dat, ok := EmbedFiles[name]
+	if !ok {
+		return nil, &EmbedFilesNotFoundError{Name: name}
+	}
+	return dat, nil
+}
+
+// EmbedFilesMustGet returns the content of the embedded file, panics if the file is not embedded.
+func EmbedFilesMustGet(name string) []byte {
+	dat, err := EmbedFilesGet(name)
+	if err != nil {
+		panic("genembed: " + err.Error())
+	}
+	return dat
+}
+
+// EmbedFilesNames returns the sorted names of the embedded files.
+func EmbedFilesNames() []string {
+	names := make([]string, 0, len(EmbedFiles))
+	for name := range EmbedFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EmbedFilesWalk calls fn for the embedded files with the names starting with the prefix in the sorted order.
+// The walk stops on the first error, the error is returned.
+func EmbedFilesWalk(prefix string, fn func(name string, dat []byte) error) error {
+	for _, name := range EmbedFilesNames() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		dat, err := EmbedFilesGet(name)
+		if err != nil {
+			return err
+		}
+		if err := fn(name, dat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmbedFilesOpen returns the reader of the content of the embedded file.
+func EmbedFilesOpen(name string) (io.ReadSeeker, error) {
+	dat, err := EmbedFilesGet(name)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(dat), nil
 }
@@ -3,11 +3,11 @@ package somepkg
 
 // EmbedFiles list of embedded files.
 var EmbedFiles = map[string][]byte{
-	// [START embeddedFiles]
+	// [START embeddedFiles EmbedFiles]
 	"somefile": []byte{
 		0x73, 0x6f, 0x6d, 0x65, 0x66, 0x69, 0x6c, 0x65, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0xa, 0x73, 0x6f, 0x6d,
 		0x65, 0x66, 0x69, 0x6c, 0x65, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0xa, 0x73, 0x6f, 0x6d, 0x65, 0x66, 0x69,
 		0x6c, 0x65, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0xa,
 	},
-	// [END embeddedFiles]
+	// [END embeddedFiles EmbedFiles]
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// check generates the variables in memory and reports the entries that differ from the output files.
+// The output files are generated as by the same command without -check: from scratch if rebuild is true,
+// otherwise the variables are added to the existing output files and pruned only with -prune.
+// The output files are not modified. Returns true if any output file is stale.
+func check(configs []config, rebuild bool, w io.Writer) (bool, error) {
+	files, _, err := renderAll(configs, rebuild)
+	if err != nil {
+		return false, err
+	}
+
+	var stale bool
+	for _, f := range files {
+		diff, err := checkOutput(f.name, f.src)
+		if err != nil {
+			return false, err
+		}
+		for _, line := range diff {
+			fmt.Fprintln(w, f.name+": "+line)
+		}
+		stale = stale || len(diff) > 0
+	}
+	return stale, nil
+}
+
+// checkOutput returns the differences between the output file and the generated one.
+func checkOutput(output string, generated []byte) ([]string, error) {
+	old, err := ioutil.ReadFile(output)
+	if os.IsNotExist(err) {
+		return []string{"not exists"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed read output file: %v", err)
+	}
+	return diffFiles(old, generated), nil
+}
+
+// diffFiles returns the list of the entries that are added, changed or removed in the new file.
+// If the entries are equal but the files differ a single line is returned.
+func diffFiles(old, new []byte) []string {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	oldEntries, err := readMapEntries(old)
+	if err != nil {
+		return []string{"differs"}
+	}
+	newEntries, err := readMapEntries(new)
+	if err != nil {
+		return []string{"differs"}
+	}
+
+	var diff []string
+	for _, name := range sortedVars(newEntries) {
+		for _, key := range sortedKeys(newEntries[name]) {
+			oldValue, ok := oldEntries[name][key]
+			switch {
+			case !ok:
+				diff = append(diff, fmt.Sprintf("%s[%q] added", name, key))
+			case oldValue != newEntries[name][key]:
+				diff = append(diff, fmt.Sprintf("%s[%q] changed", name, key))
+			}
+		}
+	}
+	for _, name := range sortedVars(oldEntries) {
+		for _, key := range sortedKeys(oldEntries[name]) {
+			if _, ok := newEntries[name][key]; !ok {
+				diff = append(diff, fmt.Sprintf("%s[%q] removed", name, key))
+			}
+		}
+	}
+
+	if len(diff) == 0 {
+		return []string{"differs"}
+	}
+	return diff
+}
+
+// readMapEntries returns the source code of the values of the map variables declared in the file
+// by the name of the variable and the key.
+func readMapEntries(src []byte) (map[string]map[string]string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]map[string]string{}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if len(spec.Names) != 1 || len(spec.Values) != 1 {
+				continue
+			}
+			lit, ok := spec.Values[0].(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			if _, ok := lit.Type.(*ast.MapType); !ok {
+				continue
+			}
+
+			entries := map[string]string{}
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				key, ok := kv.Key.(*ast.BasicLit)
+				if !ok || key.Kind != token.STRING {
+					continue
+				}
+				name, err := strconv.Unquote(key.Value)
+				if err != nil {
+					return nil, err
+				}
+				var buf bytes.Buffer
+				if err := printer.Fprint(&buf, fset, kv.Value); err != nil {
+					return nil, err
+				}
+				entries[name] = buf.String()
+			}
+			vars[spec.Names[0].Name] = entries
+		}
+	}
+	return vars, nil
+}
+
+func sortedVars(m map[string]map[string]string) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
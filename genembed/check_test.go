@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_diffFiles(t *testing.T) {
+	const old = "package a\n\nvar A = map[string][]byte{\n\"f1\": []byte(\"1\"),\n\"f2\": []byte(\"2\"),\n}\n"
+
+	tests := []struct {
+		name string
+		new  string
+		want []string
+	}{
+		{"equal", old, nil},
+		{"formatting", old + "\n", []string{"differs"}},
+		{
+			"entries",
+			"package a\n\nvar A = map[string][]byte{\n\"f1\": []byte{0x31},\n\"f3\": []byte(\"3\"),\n}\n",
+			[]string{`A["f1"] changed`, `A["f3"] added`, `A["f2"] removed`},
+		},
+		{
+			"newVariable",
+			old + "\nvar B = map[string][]byte{\n\"f1\": []byte(\"1\"),\n}\n",
+			[]string{`B["f1"] added`},
+		},
+		{"invalid", "package", []string{"differs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, diffFiles([]byte(old), []byte(tt.new)))
+		})
+	}
+}
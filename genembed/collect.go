@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// collectFiles returns the list of files to embed.
+// Directories are walked recursively and shell-style patterns (including **) are expanded,
+// the found files are skipped if they are ignored by the ignore list (nil list does not ignore files),
+// the found ignore files are always skipped. Other arguments are returned as is.
+func collectFiles(args []string, ignore *ignoreList) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		files = append(files, name)
+	}
+	addFound := func(names []string) error {
+		for _, name := range names {
+			if filepath.Base(name) == ignoreFile {
+				continue
+			}
+			if ignore != nil {
+				ignored, err := ignore.ignored(name)
+				if err != nil {
+					return err
+				}
+				if ignored {
+					continue
+				}
+			}
+			add(name)
+		}
+		return nil
+	}
+
+	for _, arg := range args {
+		if isPattern(arg) {
+			matches, err := glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match pattern %q", arg)
+			}
+			if err := addFound(matches); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			// NOTE: failed to open the file will be reported while embedding
+			add(arg)
+			continue
+		}
+
+		dirFiles, err := walkFiles(arg)
+		if err != nil {
+			return nil, err
+		}
+		if err := addFound(dirFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// excludeFiles returns the files that do not match any of the patterns.
+// The pattern matches the file or any of its parent directories.
+func excludeFiles(files []string, patterns []string) []string {
+	if len(patterns) == 0 {
+		return files
+	}
+
+	var res []string
+	for _, name := range files {
+		if !matchAny(name, patterns) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// matchAny reports whether the file or any of its parent directories matches one of the patterns.
+// As in the ignore file the pattern without / matches the name at any level.
+func matchAny(name string, patterns []string) bool {
+	name = filepath.ToSlash(filepath.Clean(name))
+	for _, pattern := range patterns {
+		pattern = path.Clean(filepath.ToSlash(pattern))
+		rule := ignoreRule{pattern: pattern, anchored: strings.Contains(pattern, "/")}
+		if rule.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkFiles returns sorted list of files in the dir and all subdirectories.
+func walkFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// glob returns sorted list of files matching the pattern.
+func glob(pattern string) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	// walking starts from the longest part of the path without special characters
+	var root []string
+	for _, segment := range strings.Split(pattern, "/") {
+		if isPattern(segment) {
+			break
+		}
+		root = append(root, segment)
+	}
+	dir := filepath.FromSlash(strings.Join(root, "/"))
+	if dir == "" {
+		dir = "."
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	files, err := walkFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, name := range files {
+		if matchPattern(pattern, filepath.ToSlash(name)) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, nil
+}
+
+// matchPattern reports whether the slash-separated name matches the pattern.
+// The pattern syntax is the same as in path.Match,
+// in addition the ** segment matches zero or more directories.
+func matchPattern(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// isPattern reports whether the path contains any of the special characters of pattern.
+func isPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
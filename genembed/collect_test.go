@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_matchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"a", "a", true},
+		{"a", "b", false},
+		{"*", "a", true},
+		{"*", "a/b", false},
+		{"a/*.txt", "a/b.txt", true},
+		{"a/*.txt", "a/b/c.txt", false},
+		{"a/**/*.txt", "a/b.txt", true},
+		{"a/**/*.txt", "a/b/c.txt", true},
+		{"a/**/*.txt", "a/b/c/d.txt", true},
+		{"a/**/*.txt", "b/c.txt", false},
+		{"a/**", "a/b/c", true},
+		{"**", "a/b/c", true},
+		{"**/c", "a/b/c", true},
+		{"**/c", "c", true},
+		{"**/b", "a/b/c", false},
+		{"a/**/b/*", "a/x/b/c", true},
+		{"a/?.txt", "a/b.txt", true},
+		{"a/[bc].txt", "a/c.txt", true},
+		{"a/[bc].txt", "a/d.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, matchPattern(tt.pattern, tt.name))
+		})
+	}
+}
+
+func Test_collectFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genembed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"f1", "assets/a.txt", "assets/b.css", "assets/sub/c.txt", "assets/sub/deep/d.txt", "assets/sub/" + ignoreFile} {
+		name = filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0700))
+		require.NoError(t, ioutil.WriteFile(name, []byte(name), 0666))
+	}
+
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(pwd)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{"file", []string{"f1"}, []string{"f1"}, ""},
+		{"notExistsFile", []string{"f2"}, []string{"f2"}, ""},
+		{"dir", []string{"assets"}, []string{"assets/a.txt", "assets/b.css", "assets/sub/c.txt", "assets/sub/deep/d.txt"}, ""},
+		{"dirSlash", []string{"./assets/sub/"}, []string{"assets/sub/c.txt", "assets/sub/deep/d.txt"}, ""},
+		{"glob", []string{"assets/*.txt"}, []string{"assets/a.txt"}, ""},
+		{"globRecursive", []string{"assets/**/*.txt"}, []string{"assets/a.txt", "assets/sub/c.txt", "assets/sub/deep/d.txt"}, ""},
+		{"globRoot", []string{"**/d.txt"}, []string{"assets/sub/deep/d.txt"}, ""},
+		{"duplicates", []string{"assets/sub/c.txt", "assets/sub", "f1"}, []string{"assets/sub/c.txt", "assets/sub/deep/d.txt", "f1"}, ""},
+		{"noMatches", []string{"assets/*.js"}, nil, `no files match pattern "assets/*.js"`},
+		{"notExistsDir", []string{"static/*"}, nil, `no files match pattern "static/*"`},
+		{"badPattern", []string{"assets/[.txt"}, nil, "syntax error in pattern"},
+		{"ignoreFile", []string{"assets/sub/*"}, []string{"assets/sub/c.txt"}, ""},
+		{"explicitIgnoreFile", []string{"assets/sub/" + ignoreFile}, []string{"assets/sub/" + ignoreFile}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectFiles(tt.args, nil)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_excludeFiles(t *testing.T) {
+	files := []string{".DS_Store", "assets/.DS_Store", "assets/a.txt", "assets/sub/b.txt", "assets/sub/c.map", "sub/d.txt"}
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"none", nil, files},
+		{"name", []string{".DS_Store"}, []string{"assets/a.txt", "assets/sub/b.txt", "assets/sub/c.map", "sub/d.txt"}},
+		{"dirName", []string{"sub"}, []string{".DS_Store", "assets/.DS_Store", "assets/a.txt"}},
+		{"nameGlob", []string{"*.map"}, []string{".DS_Store", "assets/.DS_Store", "assets/a.txt", "assets/sub/b.txt", "sub/d.txt"}},
+		{"anchored", []string{"sub/*.txt"}, []string{".DS_Store", "assets/.DS_Store", "assets/a.txt", "assets/sub/b.txt", "assets/sub/c.map"}},
+		{"anchoredDir", []string{"assets/sub/"}, []string{".DS_Store", "assets/.DS_Store", "assets/a.txt", "sub/d.txt"}},
+		{"recursive", []string{"**/*.txt"}, []string{".DS_Store", "assets/.DS_Store", "assets/sub/c.map"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, excludeFiles(files, tt.patterns))
+		})
+	}
+}
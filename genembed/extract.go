@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decompressMethods list of the decompressors of the supported compression methods.
+var decompressMethods = map[string]func(r io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"flate": func(r io.Reader) (io.ReadCloser, error) {
+		return flate.NewReader(r), nil
+	},
+	"zlib": zlib.NewReader,
+}
+
+// sectionRe matches the start pattern of the section of the generated variable.
+var sectionRe = regexp.MustCompile(`^// \[START (embeddedFiles|embeddedMetadata) (\S+)\]$`)
+
+// extractedVar is the variable with the embedded files parsed from the generated file.
+type extractedVar struct {
+	name     string // name of the variable of the generator, the map may be unexported
+	compress string
+	files    map[string][]byte
+}
+
+// extractedMetadata is the metadata of the embedded file parsed from the generated file.
+type extractedMetadata struct {
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// extract writes the embedded files of the variable from the generated file into the directory,
+// the subdirectories are created by the keys of the files. The mode and the modification time
+// of the files are restored from the metadata if the variable has metadata.
+// The name of the variable may be empty if the file has the only variable.
+func extract(filename, fieldName, dir string, w io.Writer) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed read generated file: %v", err)
+	}
+	vars, metadata, err := parseGenerated(src)
+	if err != nil {
+		return fmt.Errorf("failed parse generated file %q: %v", filename, err)
+	}
+
+	v, err := selectVar(vars, fieldName)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedFileKeys(v.files) {
+		dat, err := decompress(v.compress, v.files[key])
+		if err != nil {
+			return fmt.Errorf("failed decompress embedded file %q: %v", key, err)
+		}
+
+		path, err := extractPath(dir, key)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, dat, 0644); err != nil {
+			return fmt.Errorf("failed write extracted file: %v", err)
+		}
+
+		if md, ok := metadata[v.name][key]; ok {
+			if err := os.Chmod(path, md.mode.Perm()); err != nil {
+				return fmt.Errorf("failed change mode of extracted file: %v", err)
+			}
+			if err := os.Chtimes(path, md.modTime, md.modTime); err != nil {
+				return fmt.Errorf("failed change modification time of extracted file: %v", err)
+			}
+		}
+		fmt.Fprintf(w, "%s[%q] %s\n", v.name, key, path)
+	}
+	return nil
+}
+
+// selectVar returns the variable by the name or the only variable if the name is empty.
+func selectVar(vars []extractedVar, fieldName string) (extractedVar, error) {
+	var names []string
+	for _, v := range vars {
+		if fieldName == "" && len(vars) == 1 {
+			return v, nil
+		}
+		if v.name == fieldName {
+			return v, nil
+		}
+		names = append(names, v.name)
+	}
+	switch {
+	case len(vars) == 0:
+		return extractedVar{}, errors.New("no embedded files in the generated file")
+	case fieldName == "":
+		return extractedVar{}, fmt.Errorf("several variables %s in the generated file, use -var", strings.Join(names, ", "))
+	}
+	return extractedVar{}, fmt.Errorf("variable %s is not found in the generated file", fieldName)
+}
+
+// extractPath returns the path of the extracted file in the directory.
+func extractPath(dir, key string) (string, error) {
+	name := filepath.FromSlash(strings.TrimLeft(key, "/"))
+	path := filepath.Join(dir, name)
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || name == "" || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("key %q of the embedded file escapes the target directory", key)
+	}
+	return path, nil
+}
+
+// parseGenerated returns the variables with the embedded files
+// and the metadata of the files by the name of the variable and the key.
+// The variables are found by the start patterns of the sections in the map literals.
+func parseGenerated(src []byte) ([]extractedVar, map[string]map[string]extractedMetadata, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vars []extractedVar
+	metadata := map[string]map[string]extractedMetadata{}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if len(spec.Names) != 1 || len(spec.Values) != 1 {
+				continue
+			}
+			lit, ok := spec.Values[0].(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			mapType, ok := lit.Type.(*ast.MapType)
+			if !ok {
+				continue
+			}
+			section, name := literalSection(f, lit)
+
+			switch section {
+			case "embeddedFiles":
+				v := extractedVar{name: name, files: map[string][]byte{}}
+				if v.compress, err = compressMethod(f, name); err != nil {
+					return nil, nil, err
+				}
+				err = eachEntry(lit, func(key string, value ast.Expr) error {
+					dat, err := bytesValue(value, mapType.Value)
+					if err != nil {
+						return fmt.Errorf("%s[%q]: %v", name, key, err)
+					}
+					v.files[key] = dat
+					return nil
+				})
+				vars = append(vars, v)
+			case "embeddedMetadata":
+				entries := map[string]extractedMetadata{}
+				err = eachEntry(lit, func(key string, value ast.Expr) error {
+					if lit, ok := value.(*ast.CompositeLit); ok {
+						entries[key] = metadataValue(lit)
+					}
+					return nil
+				})
+				metadata[name] = entries
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return vars, metadata, nil
+}
+
+// compressMethod returns the compression method of the variable, empty if the files are not compressed.
+// The method is the package of the decompressor called by <Var>Get, the generated file
+// of the compressed files declares also <Var>Compressed.
+func compressMethod(f *ast.File, name string) (string, error) {
+	// NOTE: the packages may be imported with other names
+	packages := map[string]string{}
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !strings.HasPrefix(path, "compress/") {
+			continue
+		}
+		method := strings.TrimPrefix(path, "compress/")
+		if _, ok := decompressMethods[method]; !ok {
+			continue
+		}
+		if imp.Name != nil {
+			packages[imp.Name.Name] = method
+		} else {
+			packages[method] = method
+		}
+	}
+
+	methods := map[string]bool{}
+	var compressed bool
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil {
+			continue
+		}
+		switch fn.Name.Name {
+		case name + "Compressed":
+			compressed = true
+		case name + "Get":
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok || sel.Sel.Name != "NewReader" {
+					return true
+				}
+				if pkg, ok := sel.X.(*ast.Ident); ok && packages[pkg.Name] != "" {
+					methods[packages[pkg.Name]] = true
+				}
+				return true
+			})
+		}
+	}
+
+	var names []string
+	for method := range methods {
+		names = append(names, method)
+	}
+	sort.Strings(names)
+	switch {
+	case len(names) > 1:
+		return "", fmt.Errorf("ambiguous compression method of %s: %sGet calls the decompressors %s", name, name, strings.Join(names, ", "))
+	case len(names) == 1:
+		return names[0], nil
+	case compressed:
+		return "", fmt.Errorf("unknown compression method of %s: %sGet does not call the decompressor", name, name)
+	}
+	return "", nil
+}
+
+// literalSection returns the section and the name of the variable of the start pattern in the literal.
+func literalSection(f *ast.File, lit *ast.CompositeLit) (string, string) {
+	for _, group := range f.Comments {
+		if group.Pos() < lit.Lbrace || group.End() > lit.Rbrace {
+			continue
+		}
+		for _, c := range group.List {
+			if m := sectionRe.FindStringSubmatch(c.Text); m != nil {
+				return m[1], m[2]
+			}
+		}
+	}
+	return "", ""
+}
+
+// eachEntry calls fn for the entries of the map literal with the string keys.
+func eachEntry(lit *ast.CompositeLit, fn func(key string, value ast.Expr) error) error {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := constString(kv.Key, nil)
+		if !ok {
+			continue
+		}
+		if err := fn(key, kv.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBytesType reports whether the expression is the type []byte.
+func isBytesType(expr ast.Expr) bool {
+	arr, ok := expr.(*ast.ArrayType)
+	if !ok || arr.Len != nil {
+		return false
+	}
+	elt, ok := arr.Elt.(*ast.Ident)
+	return ok && (elt.Name == "byte" || elt.Name == "uint8")
+}
+
+// bytesValue returns the value of the entry encoded as the composite literal []byte{0x31, ...}
+// or the string literal converted to []byte. The type of the composite literal may be elided
+// (gofmt -s), then the literal has the type of the elements of the map.
+func bytesValue(expr ast.Expr, elemType ast.Expr) ([]byte, error) {
+	switch expr := expr.(type) {
+	case *ast.CompositeLit:
+		typ := expr.Type
+		if typ == nil {
+			typ = elemType
+		}
+		if !isBytesType(typ) {
+			break
+		}
+		dat := make([]byte, 0, len(expr.Elts))
+		for _, elt := range expr.Elts {
+			lit, ok := elt.(*ast.BasicLit)
+			if !ok || (lit.Kind != token.INT && lit.Kind != token.CHAR) {
+				return nil, fmt.Errorf("unsupported element %T of the byte slice", elt)
+			}
+			b, err := byteValue(lit)
+			if err != nil {
+				return nil, err
+			}
+			dat = append(dat, b)
+		}
+		return dat, nil
+	case *ast.CallExpr:
+		if !isBytesType(expr.Fun) || len(expr.Args) != 1 {
+			break
+		}
+		s, ok := constString(expr.Args[0], nil)
+		if !ok {
+			return nil, fmt.Errorf("unsupported argument %T of the conversion to []byte", expr.Args[0])
+		}
+		return []byte(s), nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", expr)
+}
+
+func byteValue(lit *ast.BasicLit) (byte, error) {
+	if lit.Kind == token.CHAR {
+		s, err := strconv.Unquote(lit.Value)
+		if err != nil || len(s) != 1 {
+			return 0, fmt.Errorf("invalid byte %s", lit.Value)
+		}
+		return s[0], nil
+	}
+	n, err := strconv.ParseUint(strings.Replace(lit.Value, "_", "", -1), 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte %s", lit.Value)
+	}
+	return byte(n), nil
+}
+
+// metadataValue returns the mode and the modification time of the metadata literal
+// {Size: 3, Mode: 0644, ModTime: time.Unix(1, 0), SHA256: "..."}, the invalid fields are ignored.
+func metadataValue(lit *ast.CompositeLit) extractedMetadata {
+	md := extractedMetadata{mode: 0644}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		field, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch field.Name {
+		case "Mode":
+			if mode, ok := intValue(kv.Value); ok {
+				md.mode = os.FileMode(mode)
+			}
+		case "ModTime":
+			call, ok := kv.Value.(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				continue
+			}
+			sec, ok := intValue(call.Args[0])
+			nsec, ok2 := intValue(call.Args[1])
+			if ok && ok2 {
+				md.modTime = time.Unix(sec, nsec)
+			}
+		}
+	}
+	return md
+}
+
+func intValue(expr ast.Expr) (int64, bool) {
+	sign := int64(1)
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.SUB {
+		sign, expr = -1, unary.X
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(lit.Value, 0, 64)
+	return sign * n, err == nil
+}
+
+// decompress returns the data decompressed with the method.
+// If the method is empty the data is returned as is.
+func decompress(method string, dat []byte) ([]byte, error) {
+	if method == "" {
+		return dat, nil
+	}
+	newReader, ok := decompressMethods[method]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression method %q", method)
+	}
+	r, err := newReader(bytes.NewReader(dat))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func sortedFileKeys(m map[string][]byte) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bytesValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{"bytes", "[]byte{0x31, 0x32, 50, '3', 0_7}", "1223\a", false},
+		{"string", `[]byte("1\n2")`, "1\n2", false},
+		{"rawString", "[]byte(`1\n2`)", "1\n2", false},
+		{"concatenation", `[]byte("1" + "2")`, "12", false},
+		{"empty", `[]byte("")`, "", false},
+		{"overflow", "[]byte{0x100}", "", true},
+		{"variable", "[]byte{a}", "", true},
+		{"notConversion", `"1"`, "", true},
+		{"elided", `map[string][]byte{"k": {0x31, '2'}}`, "12", false},
+		{"elidedString", `map[string]string{"k": {0x31}}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.expr)
+			require.NoError(t, err)
+			var elemType ast.Expr
+			if lit, ok := expr.(*ast.CompositeLit); ok {
+				if mapType, ok := lit.Type.(*ast.MapType); ok {
+					// NOTE: the value of the entry of the map with the elided type
+					expr, elemType = lit.Elts[0].(*ast.KeyValueExpr).Value, mapType.Value
+				}
+			}
+			got, err := bytesValue(expr, elemType)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func Test_extractPath(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"f1", filepath.Join("out", "f1"), false},
+		{"sub/f2", filepath.Join("out", "sub", "f2"), false},
+		{"/static/f3", filepath.Join("out", "static", "f3"), false},
+		{"../f4", "", true},
+		{"sub/../../f5", "", true},
+		{"/", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := extractPath("out", tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_compressMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{"plain", `func AGet(name string) ([]byte, error) { return A[name], nil }`, "", ""},
+		{"none", ``, "", ""},
+		{"gzip", `import ("bytes"; "compress/gzip")
+func AGet(name string) ([]byte, error) { r, err := gzip.NewReader(bytes.NewReader(A[name])); return nil, err }
+func ACompressed(name string) ([]byte, bool) { return nil, false }`, "gzip", ""},
+		{"flate", `import ("bytes"; "compress/flate")
+func AGet(name string) ([]byte, error) { r := flate.NewReader(bytes.NewReader(A[name])); return nil, nil }`, "flate", ""},
+		{"importName", `import ("bytes"; z "compress/zlib")
+func AGet(name string) ([]byte, error) { r, err := z.NewReader(bytes.NewReader(A[name])); return nil, err }`, "zlib", ""},
+		{"otherVar", `import ("bytes"; "compress/gzip")
+func BGet(name string) ([]byte, error) { r, err := gzip.NewReader(bytes.NewReader(B[name])); return nil, err }`, "", ""},
+		{"comment", `// AGet returns the files compressed with gzip.
+func AGet(name string) ([]byte, error) { return A[name], nil }`, "", ""},
+		{"ambiguous", `import ("bytes"; "compress/gzip"; "compress/zlib")
+func AGet(name string) ([]byte, error) { gzip.NewReader(nil); zlib.NewReader(nil); return nil, nil }`, "", "ambiguous compression method of A: AGet calls the decompressors gzip, zlib"},
+		{"unknown", `import "bytes"
+func AGet(name string) ([]byte, error) { return nil, nil }
+func ACompressed(name string) ([]byte, bool) { return nil, false }`, "", "unknown compression method of A: AGet does not call the decompressor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "", "package a\n"+tt.src, 0)
+			require.NoError(t, err)
+			got, err := compressMethod(f, "A")
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,158 +1,623 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
 	"io/ioutil"
 	"os"
-	"syscall"
-	"text/template"
+	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/gebv/genembed/file"
+	"github.com/gebv/genembed"
 )
 
+var (
+	varFlag          = flag.String("var", "", "name of the variable with embedded files (default is the first argument)")
+	outputFlag       = flag.String("o", "", "output file (default is <package>_genembed.go), the target directory of extract (default is the working directory)")
+	pkgFlag          = flag.String("pkg", "", "package name of the output file (default is $GOPACKAGE or detected from the .go files in the working directory)")
+	prefixFlag       = flag.String("prefix", "", "directory prefix to strip from the names of the embedded files")
+	keyPrefixFlag    = flag.String("key-prefix", "", "virtual directory prefix to add to the names of the embedded files")
+	keyTemplateFlag  = flag.String("key-template", "", "template of the names of the embedded files with fields .Path, .Key, .Dir, .Base, .Name, .Ext and functions lower, upper, replace, trimPrefix, trimSuffix")
+	compressFlag     = flag.String("compress", "", "compress the embedded files with gzip, flate or zlib")
+	fsFlag           = flag.Bool("fs", false, "generate <VariableName>FS function returning http.FileSystem with the embedded files")
+	encodingFlag     = flag.String("encoding", "bytes", "encoding of the embedded files in the generated code: bytes or string")
+	accessorsFlag    = flag.Bool("accessors", false, "generate <VariableName>Get, <VariableName>MustGet, <VariableName>Names, <VariableName>Walk and <VariableName>Open functions returning <VariableName>NotFoundError for not embedded files")
+	immutableFlag    = flag.Bool("immutable", false, "generate the unexported map instead of <VariableName> and the accessors returning the copies of the content (implies -accessors)")
+	constsFlag       = flag.Bool("consts", false, "generate constants <VariableName>Key... with the keys of the embedded files")
+	templateFlag     = flag.String("template", "", "file of the custom template of the generated file executed with genembed.TemplateData, the options of the generated code are ignored")
+	metadataFlag     = flag.Bool("metadata", false, "generate <VariableName>Info function returning size, mode, modification time and SHA-256 of the embedded files")
+	modTimeFlag      = flag.String("modtime", "", "fixed modification time of the embedded files in RFC 3339 format or unix seconds (default is the modification time of the file)")
+	excludeFlag      stringsFlag
+	manifestFlag     = flag.String("manifest", "", "generate the variables listed in the manifest file (default is "+defaultManifest+" if no arguments)")
+	dryRunFlag       = flag.Bool("dry-run", false, "print the files that would be embedded without writing the output files")
+	devFlag          = flag.Bool("dev", false, "generate also <output>_dev.go with build tag "+genembed.DevBuildTag+" reading the embedded files from the disk")
+	secretsFlag      = flag.String("secrets", "error", "action on the embedded files looking like secrets (private keys, SSH keys, .env files, high-entropy strings): error, warn or off")
+	allowSecretFlag  stringsFlag
+	maxFileSizeFlag  = flag.String("max-file-size", "", "maximum size of the embedded file, such as 512KB or 10MB (default is unlimited)")
+	maxTotalSizeFlag = flag.String("max-total-size", "", "maximum total size of the embedded files of the variable, such as 50MB (default is unlimited)")
+	reportFlag       = flag.String("report", "", "print the report with the sizes of the embedded files: text or json")
+	checkFlag        = flag.Bool("check", false, "report the entries that differ from the output files without modifying them, exit with non-zero status if the output files are stale")
+	pruneFlag        = flag.Bool("prune", false, "remove the embedded files of the variable that are not embedded by the arguments from the output file")
+)
+
+// Commands of genembed, the default command generates the variables.
+const (
+	commandLint    = "lint"    // report the missing and unused embedded files, see lint
+	commandExtract = "extract" // write the embedded files from the generated file, see extract
+)
+
+func init() {
+	flag.Var(&excludeFlag, "exclude", "pattern of the files that are not embedded, can be repeated (the files ignored by "+ignoreFile+" are not embedded too)")
+	flag.Var(&allowSecretFlag, "allow-secret", "pattern of the files embedded even if they look like secrets, can be repeated")
+}
+
+// stringsFlag is the flag that can be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: genembed [flags] [VariableName] files...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       genembed [-manifest genembed.json]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       genembed lint [flags] [VariableName] [files...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       genembed extract [-var VariableName] [-o dir] generated.go")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 	args := flag.Args()
-	if len(args) == 0 {
-		fmt.Println("invalid arguments")
-		os.Exit(1)
-	}
 
-	if len(args) == 1 {
-		fmt.Println("nothing to embedded")
-		os.Exit(1)
+	// NOTE: the flags of the command follow the name of the command
+	var command string
+	if len(args) > 0 && (args[0] == commandLint || args[0] == commandExtract) {
+		command = args[0]
+		flag.CommandLine.Parse(args[1:])
+		args = flag.Args()
 	}
 
-	fieldName := args[0]
-	pkgName := os.Getenv("GOPACKAGE")
-
-	filename := pkgName + "_genembed.go"
+	if command == commandExtract {
+		if len(args) != 1 {
+			fmt.Println("invalid arguments")
+			os.Exit(1)
+		}
+		dir := *outputFlag
+		if dir == "" {
+			dir = "."
+		}
+		if err := extract(args[0], *varFlag, dir, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	err := prepareDstFile(filename, embeddedFileConfig{
-		Package:   pkgName,
-		FieldName: fieldName,
-	})
-	if err != nil {
-		fmt.Println("failed prepare dst file:", err)
+	if err := validateReport(*reportFlag); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	dst, err := file.OpenFile(filename)
+	// NOTE: the manifest lists all the variables of the output files, so the output files are generated from scratch
+	var configs []config
+	var rebuild bool
+	var err error
+	switch {
+	case *manifestFlag != "":
+		configs, err = readManifest(*manifestFlag, *pkgFlag)
+		rebuild = true
+	case len(args) == 0 && *varFlag == "" && isExistsFile(defaultManifest):
+		configs, err = readManifest(defaultManifest, *pkgFlag)
+		rebuild = true
+	default:
+		configs, err = flagsConfig(command, args)
+	}
 	if err != nil {
-		fmt.Println("failed open dst file:", err)
+		fmt.Println(err)
 		os.Exit(1)
 	}
-	defer dst.Close()
-	defer gofmt(filename)
 
-	for _, filename := range args[1:] {
+	if *dryRunFlag {
+		if err := dryRun(configs, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
-		file, err := os.Open(filename)
+	if command == commandLint {
+		failed, err := lint(configs, os.Stdout)
 		if err != nil {
-			fmt.Printf("failed open embedded file %q: %v", filename, err)
+			fmt.Println(err)
 			os.Exit(1)
 		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
 
-		if err := dst.WriteBefore([]byte(pattern), []byte("\""+filename+"\": []byte{\n")); err != nil {
-			fmt.Printf("failed write to file %q: %v", filename, err)
+	if *checkFlag {
+		stale, err := check(configs, rebuild, os.Stdout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if stale {
 			os.Exit(1)
 		}
+		return
+	}
 
-		scanner := bufio.NewScanner(file)
-		rowSize := 20
-		scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-			if len(data) == 0 {
-				return 0, nil, nil
-			}
-			if atEOF || len(data) < rowSize {
-				return len(data), data, nil
-			}
-			return rowSize, data[0:rowSize], nil
-		})
+	reports, err := generate(configs, rebuild)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-		for scanner.Scan() {
-			if err := dst.WriteBefore([]byte(pattern), []byte(bytesDump(scanner.Bytes())+"\n")); err != nil {
-				fmt.Printf("failed write to file %q: %v", filename, err)
-				os.Exit(1)
-			}
+	if *reportFlag != "" {
+		if err := writeReport(os.Stdout, *reportFlag, reports); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
+	}
+}
 
-		if err := dst.WriteBefore([]byte(pattern), []byte("\n},\n")); err != nil {
-			fmt.Printf("failed write to file %q: %v", filename, err)
-			os.Exit(1)
+// config of the generation of the variable with embedded files.
+type config struct {
+	Package      string
+	Output       string
+	Var          string
+	Files        []string // files, directories and patterns to embed
+	Exclude      []string // patterns of files that are not embedded
+	Prefix       string   // prefix to strip from the keys
+	KeyPrefix    string   // prefix to add to the keys
+	KeyTemplate  string   // template of the keys, see keyTemplateData
+	Compress     string
+	Encoding     string
+	FS           bool
+	Metadata     bool
+	Accessors    bool
+	Immutable    bool
+	Consts       bool
+	Template     string                 // text of the custom template of the generated file
+	Secrets      string                 // action on the files looking like secrets, empty means secretsError
+	AllowSecrets []string               // patterns of files embedded even if they look like secrets
+	MaxFileSize  int64                  // maximum size of the file, zero means unlimited
+	MaxTotalSize int64                  // maximum total size of the files, zero means unlimited
+	Dev          bool                   // generate the development file reading the files from the disk
+	Prune        bool                   // remove the files of the variable that are not embedded now from the output file
+	ModTime      time.Time              // fixed modification time, zero means the time of the file
+	Entries      map[string]entryConfig // options of the files by slash-separated path
+}
+
+// entryConfig options of the embedded file.
+type entryConfig struct {
+	Key      string // overrides the key of the file
+	Encoding string
+	ModTime  time.Time
+}
+
+// flagsConfig returns the config from the command line flags and arguments.
+// The files are optional for lint, because lint reads the embedded files from the output file.
+func flagsConfig(command string, args []string) ([]config, error) {
+	if len(args) == 0 && *varFlag == "" {
+		return nil, errors.New("invalid arguments")
+	}
+
+	fieldName := *varFlag
+	if fieldName == "" {
+		fieldName, args = args[0], args[1:]
+	}
+
+	if len(args) == 0 && command != commandLint {
+		return nil, errors.New("nothing to embedded")
+	}
+
+	modTime, err := parseModTime(*modTimeFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modification time: %v", err)
+	}
+
+	pkgName, err := packageName(*pkgFlag)
+	if err != nil {
+		return nil, fmt.Errorf("failed detect package name: %v", err)
+	}
+
+	maxFileSize, err := parseSize(*maxFileSizeFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -max-file-size: %v", err)
+	}
+	maxTotalSize, err := parseSize(*maxTotalSizeFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -max-total-size: %v", err)
+	}
+	tpl, err := readTemplate(*templateFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config{
+		Package:      pkgName,
+		Output:       *outputFlag,
+		Var:          fieldName,
+		Files:        args,
+		Exclude:      excludeFlag,
+		Prefix:       *prefixFlag,
+		KeyPrefix:    *keyPrefixFlag,
+		KeyTemplate:  *keyTemplateFlag,
+		Compress:     *compressFlag,
+		Encoding:     *encodingFlag,
+		FS:           *fsFlag,
+		Metadata:     *metadataFlag,
+		Accessors:    *accessorsFlag,
+		Immutable:    *immutableFlag,
+		Consts:       *constsFlag,
+		Template:     tpl,
+		Secrets:      *secretsFlag,
+		AllowSecrets: allowSecretFlag,
+		MaxFileSize:  maxFileSize,
+		MaxTotalSize: maxTotalSize,
+		Dev:          *devFlag,
+		Prune:        *pruneFlag,
+		ModTime:      modTime,
+	}
+	if cfg.Output == "" {
+		cfg.Output = pkgName + "_genembed.go"
+	}
+	return []config{cfg}, cfg.validate()
+}
+
+// validate returns error if the config has invalid options.
+func (cfg config) validate() error {
+	if err := cfg.options().Validate(); err != nil {
+		return err
+	}
+	if _, err := parseKeyTemplate(cfg.KeyTemplate); err != nil {
+		return fmt.Errorf("invalid key template: %v", err)
+	}
+	if err := validateSecrets(cfg.Secrets); err != nil {
+		return err
+	}
+	for _, entry := range cfg.Entries {
+		if entry.Encoding != "" && entry.Encoding != genembed.EncodingBytes && entry.Encoding != genembed.EncodingString {
+			return fmt.Errorf("unknown encoding %q", entry.Encoding)
+		}
+	}
+	return nil
+}
+
+// options returns the options of the generator.
+func (cfg config) options() genembed.Options {
+	return genembed.Options{
+		Package:   cfg.Package,
+		Var:       cfg.Var,
+		Compress:  cfg.Compress,
+		Encoding:  cfg.Encoding,
+		FS:        cfg.FS,
+		Metadata:  cfg.Metadata,
+		Accessors: cfg.Accessors,
+		Immutable: cfg.Immutable,
+		Consts:    cfg.Consts,
+		Template:  cfg.Template,
+		ModTime:   cfg.ModTime,
+		Dev:       cfg.Dev,
+	}
+}
+
+// generate writes the embedded files of the variables to the output files.
+// The output files are written only if all the variables are generated,
+// so the previous output files are intact on any error.
+// The existing output files are ignored if rebuild is true.
+// Returns the reports with the sizes of the embedded files.
+func generate(configs []config, rebuild bool) ([]variableReport, error) {
+	files, reports, err := renderAll(configs, rebuild)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeOutputs(files); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// renderAll returns the output files with the embedded files of the variables in the order of the configs.
+// The variables with the same output file are added to the file generated in memory.
+// If rebuild is true the output files are generated from scratch, otherwise the variables
+// are added to the existing output files.
+func renderAll(configs []config, rebuild bool) ([]outputFile, []variableReport, error) {
+	var outputs []string
+	generated := map[string][]byte{}
+	read := func(filename string) ([]byte, error) {
+		if src, ok := generated[filename]; ok {
+			return src, nil
+		}
+		if rebuild {
+			return nil, nil
 		}
+		return readOutput(filename)
+	}
 
-		file.Close()
+	vars := map[string]config{}
+	for _, cfg := range configs {
+		output := filepath.Clean(cfg.Output)
+		if other, ok := vars[output]; ok && (other.Template != "" || cfg.Template != "") {
+			return nil, nil, fmt.Errorf("variables %s and %s with the custom template have the same output file %q", other.Var, cfg.Var, cfg.Output)
+		}
+		if other, ok := vars[output]; ok && other.Dev != cfg.Dev {
+			return nil, nil, fmt.Errorf("variables %s and %s with and without -dev have the same output file %q", other.Var, cfg.Var, cfg.Output)
+		}
+		vars[output] = cfg
+	}
 
+	var reports []variableReport
+	for _, cfg := range configs {
+		files, report, err := render(cfg, read)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, f := range files {
+			if _, ok := generated[f.name]; !ok {
+				outputs = append(outputs, f.name)
+			}
+			generated[f.name] = f.src
+		}
+		reports = append(reports, report)
 	}
 
+	files := make([]outputFile, len(outputs))
+	for i, name := range outputs {
+		files[i] = outputFile{name, generated[name]}
+	}
+	return files, reports, nil
 }
 
-func prepareDstFile(filename string, cfg embeddedFileConfig) error {
+// readTemplate returns the text of the custom template, empty if the filename is empty.
+func readTemplate(filename string) (string, error) {
+	if filename == "" {
+		return "", nil
+	}
+	text, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed read template: %v", err)
+	}
+	return string(text), nil
+}
 
-	_, err := os.Stat(filename)
-	var isNew bool
+// readOutput returns the contents of the output file, nil if the file does not exist.
+func readOutput(filename string) ([]byte, error) {
+	src, err := ioutil.ReadFile(filename)
 	if os.IsNotExist(err) {
-		isNew = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed read dst file: %v", err)
 	}
+	return src, nil
+}
+
+// inputFile is the file to embed.
+type inputFile struct {
+	filename string
+	key      string
+	entry    entryConfig
+}
 
-	f, err := os.OpenFile(filename, syscall.O_CREAT|syscall.O_RDWR, 0666)
+// inputFiles returns the files to embed into the variable with the keys.
+func inputFiles(cfg config) ([]inputFile, error) {
+	files, err := collectFiles(cfg.Files, newIgnoreList())
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed collect embedded files: %v", err)
 	}
-	defer f.Close()
+	files = excludeFiles(files, cfg.Exclude)
 
-	if isNew {
-		err := embeddedFileTpl.Execute(f, cfg)
+	tpl, err := parseKeyTemplate(cfg.KeyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key template: %v", err)
+	}
+
+	var res []inputFile
+	for _, filename := range files {
+		if filepath.Clean(filename) == filepath.Clean(cfg.Output) || filepath.Clean(filename) == filepath.Clean(cfg.devOutput()) {
+			// NOTE: the pattern may match the generated file
+			continue
+		}
+
+		entryCfg := cfg.Entries[filepath.ToSlash(filepath.Clean(filename))]
+		key := entryCfg.Key
+		if key == "" {
+			key, err = keyName(filename, cfg, tpl)
+		} else {
+			err = checkKey(key, filename)
+		}
 		if err != nil {
-			return fmt.Errorf("failed write tpl to file: %v", err)
+			return nil, err
+		}
+		res = append(res, inputFile{filename, key, entryCfg})
+	}
+	return res, nil
+}
+
+// dryRun prints the files that would be embedded into the variables.
+func dryRun(configs []config, w io.Writer) error {
+	for _, cfg := range configs {
+		files, err := inputFiles(cfg)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			fmt.Fprintf(w, "%s[%q] %s\n", cfg.Var, f.key, f.filename)
 		}
 	}
 	return nil
 }
 
-type embeddedFileConfig struct {
-	Package   string
-	FieldName string
+// outputFile is the generated file.
+type outputFile struct {
+	name string
+	src  []byte
 }
 
-var embeddedFileTpl = template.Must(template.New("_genembed.go").Parse(`// Code generated by github.com/gebv/go-embed. DO NOT EDIT.
-package {{.Package}}
+// render returns the output files with the embedded files of the variable:
+// the output file and the development file if enabled.
+// The read returns the current contents of the output file, nil if the file does not exist.
+// Returns also the report with the sizes of the embedded files.
+func render(cfg config, read func(filename string) ([]byte, error)) ([]outputFile, variableReport, error) {
+	files, err := inputFiles(cfg)
+	if err != nil {
+		return nil, variableReport{}, err
+	}
 
-// {{.FieldName}} list of embedded files.
-var {{.FieldName}} = map[string][]byte{
-	// [START embeddedFiles]
-	// [END embeddedFiles]
-}
-`))
+	g, err := genembed.NewGenerator(cfg.options())
+	if err != nil {
+		return nil, variableReport{}, err
+	}
 
-const pattern = "// [END embeddedFiles]\n"
+	var totalSize int64
+	added := map[string]bool{}
+	for _, f := range files {
+		filename, key, entryCfg := f.filename, f.key, f.entry
 
-func bytesDump(in []byte) string {
-	buf := new(bytes.Buffer)
-	for _, b := range in {
-		fmt.Fprintf(buf, "0x%x,", b)
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, variableReport{}, fmt.Errorf("failed open embedded file %q: %v", filename, err)
+		}
+		if cfg.MaxFileSize > 0 && info.Size() > cfg.MaxFileSize {
+			return nil, variableReport{}, fmt.Errorf("embedded file %q is too large: %s, the limit is %s (-max-file-size)",
+				filename, formatSize(info.Size()), formatSize(cfg.MaxFileSize))
+		}
+		totalSize += info.Size()
+		if cfg.MaxTotalSize > 0 && totalSize > cfg.MaxTotalSize {
+			return nil, variableReport{}, fmt.Errorf("total size of the embedded files of %s exceeds the limit %s (-max-total-size)",
+				cfg.Var, formatSize(cfg.MaxTotalSize))
+		}
+		dat, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, variableReport{}, fmt.Errorf("failed open embedded file %q: %v", filename, err)
+		}
+		if cfg.Secrets != secretsOff && !matchAny(filename, cfg.AllowSecrets) {
+			if reason := detectSecret(filename, dat); reason != "" {
+				msg := fmt.Sprintf("embedded file %q looks like a secret (%s), use -allow-secret to embed it", filename, reason)
+				if cfg.Secrets != secretsWarn {
+					return nil, variableReport{}, errors.New(msg)
+				}
+				fmt.Fprintln(os.Stderr, "warning: "+msg)
+			}
+		}
+
+		modTime := info.ModTime()
+		if !entryCfg.ModTime.IsZero() {
+			modTime = entryCfg.ModTime
+		} else if !cfg.ModTime.IsZero() {
+			modTime = cfg.ModTime
+		}
+
+		// NOTE: the development file reads the file relative to the directory of the output file
+		path, err := filepath.Rel(filepath.Dir(cfg.Output), filename)
+		if err != nil {
+			path, err = filepath.Abs(filename)
+		}
+		if err != nil {
+			return nil, variableReport{}, fmt.Errorf("failed resolve path of embedded file %q: %v", filename, err)
+		}
+
+		err = g.AddEntry(genembed.Entry{
+			Name:     key,
+			Data:     dat,
+			Mode:     info.Mode(),
+			ModTime:  modTime,
+			Encoding: entryCfg.Encoding,
+			Path:     filepath.ToSlash(path),
+		})
+		if err != nil {
+			return nil, variableReport{}, fmt.Errorf("failed encode embedded file %q: %v", filename, err)
+		}
+		added[key] = true
 	}
-	return buf.String()
-}
 
-func gofmt(filePath string) {
-	in, err := ioutil.ReadFile(filePath)
+	src, err := read(cfg.Output)
 	if err != nil {
-		fmt.Println("failed read file for formatting:", err)
-		os.Exit(1)
+		return nil, variableReport{}, err
 	}
-	out, err := format.Source(in)
+	if cfg.Prune {
+		keys, err := g.Keys(src)
+		if err != nil {
+			return nil, variableReport{}, fmt.Errorf("failed read embedded files of %s from file %q: %v", cfg.Var, cfg.Output, err)
+		}
+		for _, key := range keys {
+			if !added[key] {
+				g.Remove(key)
+			}
+		}
+	}
+	src, err = g.Update(src)
 	if err != nil {
-		fmt.Println("failed formatting:", err)
-		os.Exit(1)
+		return nil, variableReport{}, fmt.Errorf("failed write to file %q: %v", cfg.Output, err)
 	}
-	if err := ioutil.WriteFile(filePath, out, 0644); err != nil {
-		fmt.Println("failed write after formatting:", err)
-		os.Exit(1)
+	out := []outputFile{{cfg.Output, src}}
+
+	if cfg.Dev {
+		devOutput := cfg.devOutput()
+		src, err := read(devOutput)
+		if err != nil {
+			return nil, variableReport{}, err
+		}
+		src, err = g.UpdateDev(src)
+		if err != nil {
+			return nil, variableReport{}, fmt.Errorf("failed write to file %q: %v", devOutput, err)
+		}
+		out = append(out, outputFile{devOutput, src})
+	}
+	return out, newVariableReport(cfg, files, g.Stats()), nil
+}
+
+// devOutput returns the name of the development file: <output>_dev.go.
+func (cfg config) devOutput() string {
+	return strings.TrimSuffix(cfg.Output, ".go") + "_dev.go"
+}
+
+// packageName returns the name of package of the generated file.
+// If the name is not specified the package name is taken from $GOPACKAGE (set by go generate)
+// or from the .go files in the working directory.
+func packageName(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+
+	if name := os.Getenv("GOPACKAGE"); name != "" {
+		return name, nil
+	}
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		return "", err
+	}
+	for _, filename := range files {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		if name != "" && name != f.Name.Name {
+			return "", fmt.Errorf("found packages %s and %s, use -pkg flag", name, f.Name.Name)
+		}
+		name = f.Name.Name
+	}
+
+	if name == "" {
+		return "", errors.New("not found .go files, use -pkg flag")
 	}
+	return name, nil
 }
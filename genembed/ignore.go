@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFile is the name of the file with the patterns of the files that are not embedded.
+// The file applies to its directory and all subdirectories.
+const ignoreFile = ".genembedignore"
+
+// ignoreRule is the pattern of the ignore file.
+type ignoreRule struct {
+	pattern  string // slash-separated pattern, see matchPattern
+	negate   bool   // the pattern starts with ! and includes the file again
+	dirOnly  bool   // the pattern ends with / and matches only directories
+	anchored bool   // the pattern contains / and matches the path relative to the directory of the ignore file
+}
+
+// parseIgnore returns the rules of the ignore file.
+// The syntax is the same as in .gitignore: the blank lines and the lines starting with # are skipped,
+// the prefix ! negates the pattern, the trailing / matches only directories,
+// the pattern without / matches the name at any level, ** matches zero or more directories.
+func parseIgnore(dat []byte) []ignoreRule {
+	var rules []ignoreRule
+	s := bufio.NewScanner(bytes.NewReader(dat))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// match reports whether the rule matches the slash-separated path relative to the directory of the ignore file.
+// The path matches if the file or any of its parent directories matches.
+func (rule ignoreRule) match(name string) bool {
+	segments := strings.Split(name, "/")
+	for i := range segments {
+		if rule.dirOnly && i == len(segments)-1 {
+			break
+		}
+		target := strings.Join(segments[:i+1], "/")
+		if !rule.anchored {
+			target = segments[i]
+		}
+		if matchPattern(rule.pattern, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreList checks the files against the ignore files of the working directory and its subdirectories.
+type ignoreList struct {
+	rules map[string][]ignoreRule // by slash-separated directory, loaded on demand
+}
+
+func newIgnoreList() *ignoreList {
+	return &ignoreList{rules: map[string][]ignoreRule{}}
+}
+
+// ignored reports whether the file is ignored.
+// The rules of the nested directories are checked after the parent ones, the last matched rule wins.
+// The files outside the working directory are not ignored.
+func (l *ignoreList) ignored(filename string) (bool, error) {
+	name := filepath.ToSlash(filepath.Clean(filename))
+	if filepath.IsAbs(filename) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return false, err
+		}
+		rel, err := filepath.Rel(wd, filename)
+		if err != nil {
+			return false, nil
+		}
+		name = filepath.ToSlash(rel)
+	}
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return false, nil
+	}
+
+	var ignored bool
+	dir := "."
+	rel := name
+	for {
+		rules, err := l.load(dir)
+		if err != nil {
+			return false, err
+		}
+		for _, rule := range rules {
+			if rule.match(rel) {
+				ignored = !rule.negate
+			}
+		}
+
+		i := strings.Index(rel, "/")
+		if i < 0 {
+			return ignored, nil
+		}
+		dir = path.Join(dir, rel[:i])
+		rel = rel[i+1:]
+	}
+}
+
+// load returns the rules of the ignore file in the directory.
+func (l *ignoreList) load(dir string) ([]ignoreRule, error) {
+	if rules, ok := l.rules[dir]; ok {
+		return rules, nil
+	}
+	dat, err := ioutil.ReadFile(filepath.Join(filepath.FromSlash(dir), ignoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	rules := parseIgnore(dat)
+	l.rules[dir] = rules
+	return rules, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ignoreList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genembed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, dat := range map[string]string{
+		ignoreFile:                          "# comment\n\n*.bak\n.DS_Store\n/secret.txt\nfixtures/\ndocs/*.md\n!docs/keep.md\n\\#hash\n",
+		filepath.Join("assets", ignoreFile): "*.txt\n!important.txt\n",
+	} {
+		name = filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0700))
+		require.NoError(t, ioutil.WriteFile(name, []byte(dat), 0666))
+	}
+
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(pwd)
+
+	tests := []struct {
+		name    string
+		ignored bool
+	}{
+		{"index.html", false},
+		{"index.html.bak", true},
+		{"assets/sub/index.html.bak", true},
+		{".DS_Store", true},
+		{"assets/.DS_Store", true},
+		{"secret.txt", true},
+		{"assets/secret.txt", true}, // *.txt of assets/.genembedignore
+		{"sub/secret.txt", false},
+		{"fixtures/a.json", true},
+		{"assets/fixtures/deep/a.json", true},
+		{"fixtures", false}, // the file, not the directory
+		{"docs/a.md", true},
+		{"docs/keep.md", false},
+		{"docs/sub/a.md", false},
+		{"#hash", true},
+		{"assets/a.txt", true},
+		{"assets/important.txt", false},
+		{"a.txt", false},
+		{"../a.bak", false}, // outside the working directory
+		{filepath.Join(dir, "a.bak"), true},
+	}
+	l := newIgnoreList()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ignored, err := l.ignored(filepath.FromSlash(tt.name))
+			require.NoError(t, err)
+			require.Equal(t, tt.ignored, ignored)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// keyTemplateData is the data of the key template.
+type keyTemplateData struct {
+	Path string // slash-separated clean path of the file
+	Key  string // key after stripping the prefix and adding the key prefix
+	Dir  string // directory of the key
+	Base string // last element of the key
+	Name string // last element of the key without extension
+	Ext  string // extension of the key including the dot
+}
+
+// keyTemplateFuncs functions available in the key template.
+var keyTemplateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"replace":    strings.ReplaceAll,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+}
+
+// parseKeyTemplate returns the parsed key template, nil if the text is empty.
+func parseKeyTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New("key").Funcs(keyTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// keyName returns the key of the embedded file in the map.
+// The key is the slash-separated clean path of the file without the directory prefix and with the key prefix,
+// then the key is rewritten by the template (if not nil).
+// Returns error if the key escapes the base directory via "..".
+func keyName(filename string, cfg config, tpl *template.Template) (string, error) {
+	name := path.Clean(filepath.ToSlash(filename))
+	key := name
+	if prefix := path.Clean(filepath.ToSlash(cfg.Prefix)); cfg.Prefix != "" && prefix != "." {
+		switch {
+		case key == prefix:
+			key = ""
+		case prefix == "/":
+			key = strings.TrimPrefix(key, "/")
+		case strings.HasPrefix(key, prefix+"/"):
+			key = key[len(prefix)+1:]
+		}
+	}
+	if err := checkKey(key, filename); err != nil {
+		return "", err
+	}
+
+	if cfg.KeyPrefix != "" {
+		key = path.Join(filepath.ToSlash(cfg.KeyPrefix), key)
+	}
+
+	if tpl != nil {
+		base := path.Base(key)
+		data := keyTemplateData{
+			Path: name,
+			Key:  key,
+			Dir:  path.Dir(key),
+			Base: base,
+			Name: strings.TrimSuffix(base, path.Ext(base)),
+			Ext:  path.Ext(base),
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("failed execute key template for %q: %v", filename, err)
+		}
+		key = buf.String()
+		if err := checkKey(key, filename); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// checkKey returns error if the key is empty or escapes the base directory.
+func checkKey(key, filename string) error {
+	if key == "" {
+		return fmt.Errorf("empty key of the file %q", filename)
+	}
+	if key == ".." || strings.HasPrefix(key, "../") || strings.Contains(key, "/../") || strings.HasSuffix(key, "/..") {
+		return fmt.Errorf("key %q of the file %q escapes the base directory, use -prefix", key, filename)
+	}
+	return nil
+}
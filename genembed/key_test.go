@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keyName(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		cfg      config
+		want     string
+		wantErr  string
+	}{
+		{"noPrefix", "assets/f1", config{}, "assets/f1", ""},
+		{"prefix", "assets/f1", config{Prefix: "assets"}, "f1", ""},
+		{"prefixSlash", "assets/sub/f1", config{Prefix: "assets/"}, "sub/f1", ""},
+		{"prefixSegment", "assets2/f1", config{Prefix: "assets"}, "assets2/f1", ""},
+		{"prefixDot", "./assets/f1", config{Prefix: "./assets"}, "f1", ""},
+		{"clean", "./assets//sub/../f1", config{}, "assets/f1", ""},
+		{"separator", filepath.Join("assets", "sub", "f1"), config{Prefix: "assets"}, "sub/f1", ""},
+		{"keyPrefix", "assets/f1", config{Prefix: "assets", KeyPrefix: "/static/"}, "/static/f1", ""},
+		{"template", "assets/Sub/F1.HTML", config{Prefix: "assets", KeyTemplate: `{{.Dir}}/{{lower .Name}}{{lower .Ext}}`}, "Sub/f1.html", ""},
+		{"templatePath", "assets/f1.txt", config{Prefix: "assets", KeyPrefix: "v1", KeyTemplate: `{{.Key}}|{{.Path}}|{{.Base}}`}, "v1/f1.txt|assets/f1.txt|f1.txt", ""},
+		{"templateFuncs", "assets/a-b.txt", config{KeyTemplate: `{{trimSuffix (replace .Key "-" "_") ".txt"}}`}, "assets/a_b", ""},
+		{"escape", "../f1", config{}, "", `key "../f1" of the file "../f1" escapes the base directory, use -prefix`},
+		{"escapePrefix", "../assets/f1", config{Prefix: "assets"}, "", `key "../assets/f1" of the file "../assets/f1" escapes the base directory, use -prefix`},
+		{"escapeTemplate", "f1", config{KeyTemplate: `../{{.Key}}`}, "", `key "../f1" of the file "f1" escapes the base directory, use -prefix`},
+		{"empty", "assets", config{Prefix: "assets"}, "", `empty key of the file "assets"`},
+		{"emptyTemplate", "f1", config{KeyTemplate: `{{if false}}{{end}}`}, "", `empty key of the file "f1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := parseKeyTemplate(tt.cfg.KeyTemplate)
+			require.NoError(t, err)
+			got, err := keyName(tt.filename, tt.cfg, tpl)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseKeyTemplate("{{.Key")
+	require.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// keyFuncs are the suffixes of the generated functions taking the key of the file as the first argument.
+var keyFuncs = []string{"Get", "MustGet", "Open", "Info", "Compressed"}
+
+// listFuncs are the suffixes of the generated functions using all the embedded files.
+var listFuncs = []string{"Names", "Walk", "FS"}
+
+// reference is the use of the key of the embedded file in the source code.
+type reference struct {
+	pos token.Position
+	key string
+}
+
+// lint reports the references to the keys that are not embedded into the variables
+// and the embedded files that are never referenced by the Go files of the package.
+// The references are the index expressions of the map and the calls of the accessors with the constant keys.
+// The unused files are not reported if the variable is used with not constant keys or all the files are listed.
+// Returns true if any problem is reported.
+func lint(configs []config, w io.Writer) (bool, error) {
+	var failed bool
+	for _, cfg := range configs {
+		problems, err := lintVar(cfg)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range problems {
+			fmt.Fprintln(w, p)
+		}
+		failed = failed || len(problems) > 0
+	}
+	return failed, nil
+}
+
+// lintVar returns the problems of the references to the variable.
+func lintVar(cfg config) ([]string, error) {
+	src, err := readOutput(cfg.Output)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return nil, fmt.Errorf("output file %q does not exist", cfg.Output)
+	}
+	mapName := cfg.options().MapName()
+	vars, err := readMapEntries(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed parse output file %q: %v", cfg.Output, err)
+	}
+	embedded, ok := vars[mapName]
+	if !ok {
+		return nil, fmt.Errorf("variable %s is not found in the output file %q", mapName, cfg.Output)
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, filepath.Dir(cfg.Output), nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed parse package: %v", err)
+	}
+	pkg, ok := pkgs[cfg.Package]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not found in the directory of the output file %q", cfg.Package, cfg.Output)
+	}
+
+	// NOTE: the generated files use the variable with not constant keys
+	generated := map[string]bool{
+		filepath.Clean(cfg.Output):      true,
+		filepath.Clean(cfg.devOutput()): true,
+	}
+	var files []*ast.File
+	for _, filename := range sortedFiles(pkg) {
+		if !generated[filepath.Clean(filename)] {
+			files = append(files, pkg.Files[filename])
+		}
+	}
+
+	refs, dynamic := findReferences(fset, files, stringConsts(pkg), cfg.Var, mapName)
+
+	var problems []string
+	referenced := map[string]bool{}
+	for _, ref := range refs {
+		referenced[ref.key] = true
+		if _, ok := embedded[ref.key]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s[%q] is not embedded", ref.pos, cfg.Var, ref.key))
+		}
+	}
+	if !dynamic {
+		for _, key := range sortedKeys(embedded) {
+			if !referenced[key] {
+				problems = append(problems, fmt.Sprintf("%s: %s[%q] is never referenced", cfg.Output, cfg.Var, key))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// findReferences returns the references to the keys of the variable in the files.
+// Returns also true if the variable is used with not constant keys or passed as a value.
+func findReferences(fset *token.FileSet, files []*ast.File, consts map[string]string, fieldName, mapName string) ([]reference, bool) {
+	funcs := map[string]bool{}
+	for _, suffix := range keyFuncs {
+		funcs[fieldName+suffix] = true
+	}
+	uses := map[string]bool{mapName: true}
+	for _, suffix := range listFuncs {
+		uses[fieldName+suffix] = true
+	}
+	for name := range funcs {
+		uses[name] = true
+	}
+
+	var refs []reference
+	var dynamic bool
+	handled := map[*ast.Ident]bool{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var ident *ast.Ident
+			var key ast.Expr
+			switch n := n.(type) {
+			case *ast.IndexExpr:
+				if x, ok := n.X.(*ast.Ident); ok && x.Name == mapName {
+					ident, key = x, n.Index
+				}
+			case *ast.CallExpr:
+				if fn, ok := n.Fun.(*ast.Ident); ok && funcs[fn.Name] && len(n.Args) > 0 {
+					ident, key = fn, n.Args[0]
+				}
+			case *ast.Ident:
+				if uses[n.Name] && !handled[n] {
+					dynamic = true
+				}
+				return true
+			}
+			if ident == nil {
+				return true
+			}
+			value, ok := constString(key, consts)
+			if !ok {
+				return true
+			}
+			handled[ident] = true
+			refs = append(refs, reference{fset.Position(key.Pos()), value})
+			return true
+		})
+	}
+	return refs, dynamic
+}
+
+// constString returns the value of the constant string expression:
+// the string literal, the string constant of the package or the concatenation of them.
+func constString(expr ast.Expr, consts map[string]string) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(expr.Value)
+		return value, err == nil
+	case *ast.Ident:
+		value, ok := consts[expr.Name]
+		return value, ok
+	case *ast.ParenExpr:
+		return constString(expr.X, consts)
+	case *ast.BinaryExpr:
+		if expr.Op != token.ADD {
+			return "", false
+		}
+		x, ok := constString(expr.X, consts)
+		if !ok {
+			return "", false
+		}
+		y, ok := constString(expr.Y, consts)
+		return x + y, ok
+	}
+	return "", false
+}
+
+// stringConsts returns the values of the string constants declared in the package by the name,
+// including the constants with the keys generated with -consts.
+func stringConsts(pkg *ast.Package) map[string]string {
+	var specs []*ast.ValueSpec
+	for _, filename := range sortedFiles(pkg) {
+		for _, decl := range pkg.Files[filename].Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if len(spec.Names) == len(spec.Values) {
+					specs = append(specs, spec)
+				}
+			}
+		}
+	}
+
+	// NOTE: the constants may refer to the constants declared later
+	consts := map[string]string{}
+	for resolved := true; resolved; {
+		resolved = false
+		for _, spec := range specs {
+			for i, name := range spec.Names {
+				if _, ok := consts[name.Name]; ok {
+					continue
+				}
+				if value, ok := constString(spec.Values[i], consts); ok {
+					consts[name.Name] = value
+					resolved = true
+				}
+			}
+		}
+	}
+	return consts
+}
+
+func sortedFiles(pkg *ast.Package) []string {
+	var names []string
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		dynamic bool
+	}{
+		{"index", `dat := A["f1"]; A["f2"] = nil`, []string{"f1", "f2"}, false},
+		{"accessors", `AGet("f1"); AMustGet("f2"); AOpen("f3"); AInfo("f4")`, []string{"f1", "f2", "f3", "f4"}, false},
+		{"consts", `_ = A[AKeyF1]; _ = A[dir + "/f2"]; _ = A[("f3")]`, []string{"f1", "sub/f2", "f3"}, false},
+		{"variableKey", `name := "f1"; _ = A["f2"]; _ = A[name]`, []string{"f2"}, true},
+		{"listed", `_ = ANames()`, nil, true},
+		{"value", `m := A; _ = m`, nil, true},
+		{"otherVariable", `_ = B["f1"]; _ = BGet("f2")`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package a\n\nconst (\n\tAKeyF1 = \"f1\"\n\tdir = sub\n\tsub = \"sub\"\n)\n\nfunc f() {\n\t" + tt.src + "\n}\n"
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "a.go", src, 0)
+			require.NoError(t, err)
+
+			pkg := &ast.Package{Name: "a", Files: map[string]*ast.File{"a.go": f}}
+			refs, dynamic := findReferences(fset, []*ast.File{f}, stringConsts(pkg), "A", "A")
+			var keys []string
+			for _, ref := range refs {
+				keys = append(keys, ref.key)
+			}
+			require.Equal(t, tt.want, keys)
+			require.Equal(t, tt.dynamic, dynamic)
+		})
+	}
+}
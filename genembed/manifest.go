@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultManifest is the manifest used if genembed is run without arguments.
+const defaultManifest = "genembed.json"
+
+// manifest describes the variables with embedded files of the package.
+//
+//	{
+//		"package": "main",
+//		"output": "main_genembed.go",
+//		"variables": [
+//			{
+//				"name": "Static",
+//				"include": ["static"],
+//				"exclude": ["**/*.map"],
+//				"prefix": "static",
+//				"compress": "gzip",
+//				"entries": [
+//					{"path": "static/index.html", "key": "index.html", "encoding": "string"}
+//				]
+//			}
+//		]
+//	}
+type manifest struct {
+	Package   string             `json:"package"` // default is $GOPACKAGE or detected from the .go files
+	Output    string             `json:"output"`  // default is <package>_genembed.go
+	Variables []manifestVariable `json:"variables"`
+}
+
+// manifestVariable options of the variable. The options have the same meaning as the flags.
+type manifestVariable struct {
+	Name         string          `json:"name"`
+	Output       string          `json:"output"` // overrides the output of the manifest
+	Include      []string        `json:"include"`
+	Exclude      []string        `json:"exclude"`
+	Prefix       string          `json:"prefix"`
+	KeyPrefix    string          `json:"key-prefix"`
+	KeyTemplate  string          `json:"key-template"`
+	Compress     string          `json:"compress"`
+	Encoding     string          `json:"encoding"`
+	FS           bool            `json:"fs"`
+	Metadata     bool            `json:"metadata"`
+	Accessors    bool            `json:"accessors"`
+	Immutable    bool            `json:"immutable"`
+	Consts       bool            `json:"consts"`
+	Template     string          `json:"template"`
+	Secrets      string          `json:"secrets"`
+	AllowSecrets []string        `json:"allow-secrets"`
+	MaxFileSize  string          `json:"max-file-size"`
+	MaxTotalSize string          `json:"max-total-size"`
+	Dev          bool            `json:"dev"`
+	ModTime      string          `json:"modtime"`
+	Entries      []manifestEntry `json:"entries"`
+}
+
+// manifestEntry options of the embedded file. The file is embedded even if it is not included by patterns.
+type manifestEntry struct {
+	Path     string `json:"path"`
+	Key      string `json:"key"`
+	Encoding string `json:"encoding"`
+	ModTime  string `json:"modtime"`
+}
+
+// readManifest returns the configs of the variables listed in the manifest.
+// The package name from the flag overrides the package of the manifest.
+func readManifest(filename, pkgName string) ([]config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed open manifest: %v", err)
+	}
+	defer f.Close()
+
+	var m manifest
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed parse manifest %q: %v", filename, err)
+	}
+
+	if len(m.Variables) == 0 {
+		return nil, errors.New("nothing to embedded")
+	}
+
+	if pkgName == "" {
+		pkgName = m.Package
+	}
+	pkgName, err = packageName(pkgName)
+	if err != nil {
+		return nil, fmt.Errorf("failed detect package name: %v", err)
+	}
+
+	output := m.Output
+	if output == "" {
+		output = pkgName + "_genembed.go"
+	}
+
+	var configs []config
+	for _, v := range m.Variables {
+		cfg := config{
+			Package:      pkgName,
+			Output:       v.Output,
+			Var:          v.Name,
+			Files:        v.Include,
+			Exclude:      v.Exclude,
+			Prefix:       v.Prefix,
+			KeyPrefix:    v.KeyPrefix,
+			KeyTemplate:  v.KeyTemplate,
+			Compress:     v.Compress,
+			Encoding:     v.Encoding,
+			FS:           v.FS,
+			Metadata:     v.Metadata,
+			Accessors:    v.Accessors,
+			Immutable:    v.Immutable,
+			Consts:       v.Consts,
+			Secrets:      v.Secrets,
+			AllowSecrets: v.AllowSecrets,
+			Dev:          v.Dev,
+			Entries:      map[string]entryConfig{},
+		}
+		if cfg.Output == "" {
+			cfg.Output = output
+		}
+		if cfg.Encoding == "" {
+			cfg.Encoding = "bytes"
+		}
+		if cfg.ModTime, err = parseModTime(v.ModTime); err != nil {
+			return nil, fmt.Errorf("invalid modification time of %s: %v", v.Name, err)
+		}
+		if cfg.MaxFileSize, err = parseSize(v.MaxFileSize); err != nil {
+			return nil, fmt.Errorf("invalid max-file-size of %s: %v", v.Name, err)
+		}
+		if cfg.MaxTotalSize, err = parseSize(v.MaxTotalSize); err != nil {
+			return nil, fmt.Errorf("invalid max-total-size of %s: %v", v.Name, err)
+		}
+		if cfg.Template, err = readTemplate(v.Template); err != nil {
+			return nil, fmt.Errorf("invalid template of %s: %v", v.Name, err)
+		}
+
+		for _, e := range v.Entries {
+			modTime, err := parseModTime(e.ModTime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid modification time of %s: %v", e.Path, err)
+			}
+			cfg.Files = append(cfg.Files, e.Path)
+			cfg.Entries[filepath.ToSlash(filepath.Clean(e.Path))] = entryConfig{
+				Key:      e.Key,
+				Encoding: e.Encoding,
+				ModTime:  modTime,
+			}
+		}
+
+		if len(cfg.Files) == 0 {
+			return nil, fmt.Errorf("nothing to embedded in %s", v.Name)
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, err
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// isExistsFile reports whether the file exists.
+func isExistsFile(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genembed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{"invalidJSON", `{`, "unexpected EOF"},
+		{"unknownField", `{"vars": []}`, `unknown field "vars"`},
+		{"noVariables", `{"variables": []}`, "nothing to embedded"},
+		{"noFiles", `{"variables": [{"name": "A"}]}`, "nothing to embedded in A"},
+		{"noName", `{"variables": [{"include": ["a"]}]}`, "empty name of the variable"},
+		{"unknownEncoding", `{"variables": [{"name": "A", "entries": [{"path": "a", "encoding": "hex"}]}]}`, `unknown encoding "hex"`},
+		{"unknownCompression", `{"variables": [{"name": "A", "include": ["a"], "compress": "lzma"}]}`, `unknown compression method "lzma"`},
+		{"invalidModTime", `{"variables": [{"name": "A", "include": ["a"], "modtime": "now"}]}`, "invalid modification time of A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, tt.name+".json")
+			require.NoError(t, ioutil.WriteFile(filename, []byte(tt.in), 0666))
+
+			_, err := readManifest(filename, "main")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		filename := filepath.Join(dir, "ok.json")
+		require.NoError(t, ioutil.WriteFile(filename, []byte(`{
+			"package": "other",
+			"variables": [
+				{"name": "A", "include": ["a", "b/**"], "exclude": ["*.map"], "fs": true},
+				{"name": "B", "output": "b.go", "encoding": "string", "entries": [{"path": "./c/d", "key": "d", "modtime": "1"}]}
+			]
+		}`), 0666))
+
+		got, err := readManifest(filename, "main")
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+
+		require.Equal(t, "main", got[0].Package)
+		require.Equal(t, "main_genembed.go", got[0].Output)
+		require.Equal(t, []string{"a", "b/**"}, got[0].Files)
+		require.Equal(t, []string{"*.map"}, got[0].Exclude)
+		require.Equal(t, "bytes", got[0].Encoding)
+		require.True(t, got[0].FS)
+
+		require.Equal(t, "b.go", got[1].Output)
+		require.Equal(t, []string{"./c/d"}, got[1].Files)
+		require.Equal(t, "d", got[1].Entries["c/d"].Key)
+		require.Equal(t, int64(1), got[1].Entries["c/d"].ModTime.Unix())
+	})
+}
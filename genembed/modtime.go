@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// parseModTime returns the time parsed from RFC 3339 format or unix seconds.
+// Returns zero time for empty value.
+func parseModTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gebv/genembed"
+)
+
+// Formats of the report.
+const (
+	reportText = "text"
+	reportJSON = "json"
+)
+
+// variableReport is the sizes of the embedded files of the variable.
+type variableReport struct {
+	Name           string       `json:"name"`
+	Output         string       `json:"output"`
+	Files          []fileReport `json:"files"`
+	Size           int64        `json:"size"`
+	CompressedSize int64        `json:"compressed_size"`
+	EncodedSize    int64        `json:"encoded_size"`
+	Ratio          float64      `json:"ratio"`
+}
+
+// fileReport is the sizes of the embedded file.
+type fileReport struct {
+	Key            string  `json:"key"`
+	Path           string  `json:"path"`
+	Size           int64   `json:"size"`            // size of the file
+	CompressedSize int64   `json:"compressed_size"` // equals to the size if the files are not compressed
+	EncodedSize    int64   `json:"encoded_size"`    // size of the entry in the generated code
+	Ratio          float64 `json:"ratio"`           // compressed size to size
+}
+
+// newVariableReport returns the report of the variable from the stats of the generator.
+func newVariableReport(cfg config, files []inputFile, stats []genembed.Stats) variableReport {
+	paths := make(map[string]string, len(files))
+	for _, f := range files {
+		paths[f.key] = f.filename
+	}
+
+	r := variableReport{Name: cfg.Var, Output: cfg.Output, Files: []fileReport{}}
+	for _, s := range stats {
+		r.Files = append(r.Files, fileReport{
+			Key:            s.Name,
+			Path:           paths[s.Name],
+			Size:           s.Size,
+			CompressedSize: s.CompressedSize,
+			EncodedSize:    s.EncodedSize,
+			Ratio:          ratio(s.CompressedSize, s.Size),
+		})
+		r.Size += s.Size
+		r.CompressedSize += s.CompressedSize
+		r.EncodedSize += s.EncodedSize
+	}
+	r.Ratio = ratio(r.CompressedSize, r.Size)
+	return r
+}
+
+func ratio(compressed, size int64) float64 {
+	if size == 0 {
+		return 1
+	}
+	return float64(compressed) / float64(size)
+}
+
+func validateReport(format string) error {
+	if format != "" && format != reportText && format != reportJSON {
+		return fmt.Errorf("unknown report format %q", format)
+	}
+	return nil
+}
+
+// writeReport writes the report of the variables in the format to w.
+func writeReport(w io.Writer, format string, reports []variableReport) error {
+	if format == reportJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(struct {
+			Variables []variableReport `json:"variables"`
+		}{reports})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIABLE\tKEY\tSIZE\tCOMPRESSED\tRATIO\tENCODED")
+	for _, r := range reports {
+		for _, f := range r.Files {
+			fmt.Fprintf(tw, "%s\t%q\t%s\t%s\t%.2f\t%s\n", r.Name, f.Key, formatSize(f.Size), formatSize(f.CompressedSize), f.Ratio, formatSize(f.EncodedSize))
+		}
+		fmt.Fprintf(tw, "%s\ttotal\t%s\t%s\t%.2f\t%s\n", r.Name, formatSize(r.Size), formatSize(r.CompressedSize), r.Ratio, formatSize(r.EncodedSize))
+	}
+	return tw.Flush()
+}
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize returns the size in bytes parsed from the number with optional unit B, KB, MB or GB (powers of 1024).
+// Returns zero for empty value.
+func parseSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	s := strings.ToUpper(strings.TrimSpace(value))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// formatSize returns the human-readable size, such as 512 B or 1.5 MB.
+func formatSize(n int64) string {
+	for _, u := range sizeUnits {
+		if n >= u.size && u.size > 1 {
+			return strconv.FormatFloat(float64(n)/float64(u.size), 'f', 1, 64) + " " + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + " B"
+}
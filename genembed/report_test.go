@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/gebv/genembed"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"100", 100, false},
+		{"100B", 100, false},
+		{"512KB", 512 << 10, false},
+		{"1.5 mb", 3 << 19, false},
+		{"2GB", 2 << 30, false},
+		{"MB", 0, true},
+		{"-1KB", 0, true},
+		{"10TB", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSize(tt.value)
+			if tt.wantErr {
+				require.EqualError(t, err, "invalid size \""+tt.value+"\"")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	require.Equal(t, "512 B", formatSize(512))
+	require.Equal(t, "1.5 KB", formatSize(1536))
+	require.Equal(t, "2.0 MB", formatSize(2<<20))
+}
+
+func Test_writeReport(t *testing.T) {
+	files := []inputFile{{filename: "assets/f1", key: "f1"}, {filename: "assets/f2", key: "f2"}}
+	stats := []genembed.Stats{
+		{Name: "f1", Size: 2048, CompressedSize: 512, EncodedSize: 3000},
+		{Name: "f2", Size: 0, CompressedSize: 0, EncodedSize: 20},
+	}
+	r := newVariableReport(config{Var: "A", Output: "a.go"}, files, stats)
+	require.Equal(t, int64(2048), r.Size)
+	require.Equal(t, int64(3020), r.EncodedSize)
+	require.Equal(t, 0.25, r.Ratio)
+	require.Equal(t, "assets/f1", r.Files[0].Path)
+	require.Equal(t, 1.0, r.Files[1].Ratio)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, reportText, []variableReport{r}))
+	require.Equal(t, `VARIABLE  KEY    SIZE    COMPRESSED  RATIO  ENCODED
+A         "f1"   2.0 KB  512 B       0.25   2.9 KB
+A         "f2"   0 B     0 B         1.00   20 B
+A         total  2.0 KB  512 B       0.25   2.9 KB
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeReport(&buf, reportJSON, []variableReport{r}))
+	var got struct {
+		Variables []variableReport `json:"variables"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Equal(t, []variableReport{r}, got.Variables)
+	require.Contains(t, buf.String(), `"compressed_size": 512,`)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Actions on the embedded files looking like secrets.
+const (
+	secretsError = "error" // fail the generation
+	secretsWarn  = "warn"  // print the warning and embed the file
+	secretsOff   = "off"   // do not scan the files
+)
+
+func validateSecrets(action string) error {
+	switch action {
+	case "", secretsError, secretsWarn, secretsOff:
+		return nil
+	}
+	return fmt.Errorf("unknown secrets action %q", action)
+}
+
+// envTemplates are the suffixes of the .env files without the secrets.
+var envTemplates = []string{".example", ".sample", ".template", ".dist"}
+
+// sshKeyNames are the default names of the SSH private keys.
+var sshKeyNames = map[string]bool{
+	"id_rsa":     true,
+	"id_dsa":     true,
+	"id_ecdsa":   true,
+	"id_ed25519": true,
+}
+
+// privateKeyRe matches the header of PEM block with private key, such as RSA, EC, OPENSSH or PGP key.
+// The submatch is the label of the block.
+var privateKeyRe = regexp.MustCompile(`-----BEGIN ((?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?)-----`)
+
+// base64Chars are the chars of the base64 encoded lines of PEM block, including the PGP checksum.
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="
+
+const (
+	minTokenLen    = 32   // minimum length of the high-entropy string
+	minEntropy     = 4.0  // minimum Shannon entropy in bits per char of the high-entropy string
+	minClassChange = 0.45 // minimum share of the adjacent chars of different classes, separates the random strings from the identifiers
+	binaryPrefix   = 8000 // the file is binary if the prefix contains NUL byte
+)
+
+// detectSecret returns the reason why the file looks like a secret, empty if it does not.
+// The file looks like a secret if it is the .env file, the SSH key, contains the private key in PEM format
+// or the high-entropy string (API tokens, passwords) in the text file.
+func detectSecret(filename string, dat []byte) string {
+	base := filepath.Base(filename)
+	if base == ".env" || strings.HasPrefix(base, ".env.") && !hasAnySuffix(base, envTemplates) {
+		return "environment file"
+	}
+	if sshKeyNames[base] || bytes.HasPrefix(dat, []byte("PuTTY-User-Key-File-")) {
+		return "SSH private key"
+	}
+	if label := privateKeyLabel(dat); label != "" {
+		if strings.Contains(label, "OPENSSH") {
+			return "SSH private key"
+		}
+		return "private key"
+	}
+
+	// NOTE: the binary files are random enough to look like secrets
+	if bytes.IndexByte(dat[:min(len(dat), binaryPrefix)], 0) >= 0 {
+		return ""
+	}
+	if line := highEntropyLine(dat); line > 0 {
+		return fmt.Sprintf("high-entropy string at line %d", line)
+	}
+	return ""
+}
+
+// privateKeyLabel returns the label of the first PEM block with private key, empty if not found.
+// The block is the BEGIN line, the base64 body and the END line with the same label,
+// so the text mentioning the header only is not the private key.
+func privateKeyLabel(dat []byte) string {
+	for _, m := range privateKeyRe.FindAllSubmatchIndex(dat, -1) {
+		label := string(dat[m[2]:m[3]])
+		rest := dat[m[1]:]
+		end := bytes.Index(rest, []byte("-----END "+label+"-----"))
+		if end >= 0 && isPEMBody(rest[:end]) {
+			return label
+		}
+	}
+	return ""
+}
+
+// isPEMBody reports whether the body of PEM block contains the base64 lines,
+// optionally preceded by the headers such as Proc-Type or Version.
+func isPEMBody(body []byte) bool {
+	var encoded bool
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.Trim(line, base64Chars) == "":
+			encoded = true
+		case !encoded && strings.Contains(line, ": "):
+		default:
+			return false
+		}
+	}
+	return encoded
+}
+
+// highEntropyLine returns the number of the first line with the high-entropy string, 0 if not found.
+// The string is the sequence of base64 chars (including URL-safe) mixing upper and lower case letters and digits.
+// The base64 data URLs and the hashes are skipped, see isEncodedData.
+func highEntropyLine(dat []byte) int {
+	line := 1
+	for i := 0; i < len(dat); {
+		if !isTokenChar(dat[i]) {
+			if dat[i] == '\n' {
+				line++
+			}
+			i++
+			continue
+		}
+		j := i
+		for j < len(dat) && isTokenChar(dat[j]) {
+			j++
+		}
+		if token := dat[i:j]; len(token) >= minTokenLen && !isEncodedData(dat[:i], token) && isRandom(token) {
+			return line
+		}
+		i = j
+	}
+	return 0
+}
+
+func isTokenChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '+' || c == '/' || c == '=' || c == '_' || c == '-'
+}
+
+// isEncodedData reports whether the token is the content of data URL or the hash of subresource integrity or go.sum.
+func isEncodedData(before, token []byte) bool {
+	if bytes.HasSuffix(before, []byte("base64,")) || bytes.HasSuffix(before, []byte("h1:")) {
+		return true
+	}
+	for _, prefix := range []string{"sha256-", "sha384-", "sha512-"} {
+		if bytes.HasPrefix(token, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRandom reports whether the token looks like the random string:
+// contains upper and lower case letters and digits, has high entropy and the classes of the adjacent letters and digits
+// change often (unlike the identifiers such as OpAMD64MOVQload, the words and the paths).
+func isRandom(token []byte) bool {
+	var classes [4]bool
+	var changes, n int
+	prev := classOther
+	for _, c := range token {
+		class := charClass(c)
+		classes[class] = true
+		if class == classOther {
+			continue
+		}
+		if n > 0 && class != prev {
+			changes++
+		}
+		prev = class
+		n++
+	}
+	if !classes[classUpper] || !classes[classLower] || !classes[classDigit] {
+		return false
+	}
+	return float64(changes)/float64(n-1) >= minClassChange && entropy(token) >= minEntropy
+}
+
+const (
+	classUpper = iota
+	classLower
+	classDigit
+	classOther
+)
+
+func charClass(c byte) int {
+	switch {
+	case 'A' <= c && c <= 'Z':
+		return classUpper
+	case 'a' <= c && c <= 'z':
+		return classLower
+	case '0' <= c && c <= '9':
+		return classDigit
+	}
+	return classOther
+}
+
+// entropy returns Shannon entropy of the token in bits per char.
+func entropy(token []byte) float64 {
+	var counts [256]int
+	for _, c := range token {
+		counts[c]++
+	}
+	var res float64
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(len(token))
+		res -= p * math.Log2(p)
+	}
+	return res
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
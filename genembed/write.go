@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeOutputs writes the output files atomically: the contents are written to the temporary files
+// in the directories of the output files, then the temporary files are renamed to the output files.
+// The output files are not modified if any temporary file is not written.
+func writeOutputs(files []outputFile) error {
+	temps := make([]string, 0, len(files))
+	defer func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+	}()
+
+	for _, f := range files {
+		temp, err := writeTemp(f.name, f.src)
+		if err != nil {
+			return fmt.Errorf("failed write dst file: %v", err)
+		}
+		temps = append(temps, temp)
+	}
+
+	for i, f := range files {
+		if err := os.Rename(temps[i], f.name); err != nil {
+			return fmt.Errorf("failed write dst file: %v", err)
+		}
+	}
+	temps = temps[:0]
+	return nil
+}
+
+// writeTemp writes the contents of the file to the temporary file in the directory of the file
+// and returns the name of the temporary file. The temporary file has the mode of the existing file or 0644.
+func writeTemp(filename string, src []byte) (string, error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	// NOTE: the temporary file is hidden from go build by the dot prefix
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(src)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genembed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	require.NoError(t, ioutil.WriteFile(a, []byte("old"), 0600))
+
+	// the output files are not modified if any file is not written
+	err = writeOutputs([]outputFile{{a, []byte("new")}, {filepath.Join(dir, "missing", "c.go"), []byte("new")}})
+	require.Error(t, err)
+	dat, err := ioutil.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "old", string(dat))
+	names, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, names, 1, "temporary files are removed")
+
+	require.NoError(t, writeOutputs([]outputFile{{a, []byte("new")}, {b, []byte("b")}}))
+	dat, err = ioutil.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(dat))
+	info, err := os.Stat(a)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode(), "mode of the existing file is kept")
+	info, err = os.Stat(b)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode())
+	names, err = ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+}
@@ -0,0 +1,392 @@
+// Package genembed generates Go source code with the files embedded into the map variables.
+//
+// The command github.com/gebv/genembed/genembed is a wrapper over this package.
+//
+//	g, err := genembed.NewGenerator(genembed.Options{Package: "main", Var: "Static"})
+//	if err != nil {
+//		return err
+//	}
+//	if err := g.AddFile("index.html", "static/index.html"); err != nil {
+//		return err
+//	}
+//	_, err = g.WriteTo(w)
+package genembed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// Encodings of the embedded files in the generated code.
+const (
+	EncodingBytes  = "bytes"  // composite literal []byte{0x31, 0x32, ...}
+	EncodingString = "string" // string literal converted to []byte
+)
+
+// Options of the generated variable.
+type Options struct {
+	Package   string    // package name of the generated file
+	Var       string    // name of the variable with embedded files
+	Compress  string    // compression method: gzip, flate or zlib, empty if the files are not compressed
+	Encoding  string    // encoding of the embedded files, default is EncodingBytes
+	FS        bool      // generate <Var>FS function returning http.FileSystem
+	Metadata  bool      // generate <Var>Info function returning metadata of the files
+	Accessors bool      // generate <Var>Get, <Var>MustGet, <Var>Names, <Var>Walk and <Var>Open functions
+	Immutable bool      // generate the unexported map instead of <Var>, the accessors return the copies of the content
+	Consts    bool      // generate the constants <Var>Key... with the keys of the files
+	ModTime   time.Time // modification time of the entries without the time
+	Dev       bool      // generate also the development file reading the files from the disk, see UpdateDev
+	Template  string    // text of the custom template of the generated file with TemplateData and TemplateFuncs
+}
+
+// Validate returns error if the options are invalid.
+func (opts Options) Validate() error {
+	if opts.Var == "" {
+		return errors.New("empty name of the variable")
+	}
+	if _, ok := compressMethods[opts.Compress]; opts.Compress != "" && !ok {
+		return fmt.Errorf("unknown compression method %q", opts.Compress)
+	}
+	if opts.Template != "" {
+		if opts.Dev {
+			return errors.New("development mode is not supported with the custom template")
+		}
+		if _, err := parseTemplate(opts.Template); err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+	}
+	return validateEncoding(opts.Encoding)
+}
+
+// MapName returns the name of the map variable with the embedded files:
+// the unexported <var>Files if the files are immutable, otherwise Var.
+func (opts Options) MapName() string {
+	if opts.Immutable {
+		return unexported(opts.Var) + "Files"
+	}
+	return opts.Var
+}
+
+func validateEncoding(encoding string) error {
+	if encoding != "" && encoding != EncodingBytes && encoding != EncodingString {
+		return fmt.Errorf("unknown encoding %q", encoding)
+	}
+	return nil
+}
+
+// Entry is the embedded file.
+type Entry struct {
+	Name     string // key of the file in the map
+	Data     []byte
+	Mode     os.FileMode
+	ModTime  time.Time // zero means the modification time of the options
+	Encoding string    // overrides the encoding of the options
+	Path     string    // path of the file read by the development file, relative to the directory of the generated file
+}
+
+// Stats is the size of the embedded file.
+type Stats struct {
+	Name           string
+	Size           int64 // size of the file
+	CompressedSize int64 // size of the compressed file, equals to Size if the files are not compressed
+	EncodedSize    int64 // size of the entry in the generated code
+}
+
+// Generator generates the variable with embedded files.
+type Generator struct {
+	opts     Options
+	entries  []entry
+	metadata []entry
+	paths    []entry // paths of the files for the development file
+	stats    []Stats
+	removed  map[string]bool // keys of the entries removed from the existing generated file
+	tpl      *template.Template
+	files    []TemplateEntry // files of the custom template
+}
+
+// NewGenerator returns the generator of the variable.
+func NewGenerator(opts Options) (*Generator, error) {
+	if opts.Encoding == "" {
+		opts.Encoding = EncodingBytes
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	g := &Generator{opts: opts}
+	if opts.Template != "" {
+		g.tpl, _ = parseTemplate(opts.Template)
+	}
+	return g, nil
+}
+
+// Add adds the contents read from r as the file with the name.
+func (g *Generator) Add(name string, r io.Reader) error {
+	dat, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return g.AddEntry(Entry{Name: name, Data: dat})
+}
+
+// AddFile adds the file from the disk with the name.
+// The mode and the modification time are taken from the file,
+// the fixed modification time of the options overrides the time of the file.
+// The filename is used as the path of the development file.
+func (g *Generator) AddFile(name, filename string) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	e := Entry{Name: name, Data: dat, Mode: info.Mode(), Path: filepath.ToSlash(filename)}
+	if g.opts.ModTime.IsZero() {
+		e.ModTime = info.ModTime()
+	}
+	return g.AddEntry(e)
+}
+
+// AddEntry adds the file. The file added later replaces the file with the same name.
+// The path of the entry is required in the development mode.
+func (g *Generator) AddEntry(e Entry) error {
+	if err := validateEncoding(e.Encoding); err != nil {
+		return err
+	}
+	if g.opts.Dev && e.Path == "" {
+		return fmt.Errorf("empty path of the embedded file %q in development mode", e.Name)
+	}
+	encoding := g.opts.Encoding
+	if e.Encoding != "" {
+		encoding = e.Encoding
+	}
+
+	dat, err := compress(g.opts.Compress, e.Data)
+	if err != nil {
+		return err
+	}
+	src := embeddedEntry(e.Name, dat, encoding)
+	delete(g.removed, e.Name)
+	g.entries = append(g.entries, entry{e.Name, src})
+	g.stats = append(g.stats, Stats{Name: e.Name, Size: int64(len(e.Data)), CompressedSize: int64(len(dat)), EncodedSize: int64(len(src))})
+
+	if g.opts.Metadata {
+		modTime := e.ModTime
+		if modTime.IsZero() {
+			modTime = g.opts.ModTime
+		}
+		g.metadata = append(g.metadata, entry{e.Name, metadataEntry(e.Name, e.Data, e.Mode, modTime)})
+	}
+	if g.opts.Dev {
+		g.paths = append(g.paths, entry{e.Name, pathEntry(e.Name, e.Path)})
+	}
+	if g.tpl != nil {
+		modTime := e.ModTime
+		if modTime.IsZero() {
+			modTime = g.opts.ModTime
+		}
+		sum := sha256.Sum256(e.Data)
+		g.files = append(g.files, TemplateEntry{
+			Name:     e.Name,
+			Bytes:    dat,
+			Size:     int64(len(e.Data)),
+			Hash:     hex.EncodeToString(sum[:]),
+			Mode:     e.Mode,
+			ModTime:  modTime,
+			Encoding: encoding,
+		})
+	}
+	return nil
+}
+
+// Remove removes the file with the name: the file added before is not generated
+// and the entry of the file is deleted from the existing generated file on update.
+func (g *Generator) Remove(name string) {
+	if g.removed == nil {
+		g.removed = map[string]bool{}
+	}
+	g.removed[name] = true
+	g.entries = withoutEntry(g.entries, name)
+	g.metadata = withoutEntry(g.metadata, name)
+	g.paths = withoutEntry(g.paths, name)
+
+	files := g.files[:0]
+	for _, f := range g.files {
+		if f.Name != name {
+			files = append(files, f)
+		}
+	}
+	g.files = files
+
+	stats := g.stats[:0]
+	for _, s := range g.stats {
+		if s.Name != name {
+			stats = append(stats, s)
+		}
+	}
+	g.stats = stats
+}
+
+// Keys returns the keys of the files of the variable in the existing generated file src
+// in the order of the file, so the files that are not added any more can be removed with Remove.
+func (g *Generator) Keys(src []byte) ([]string, error) {
+	_, sections, err := splitSections(src, g.sectionDecls())
+	if err != nil {
+		return nil, fmt.Errorf("failed parse generated file: %v", err)
+	}
+	var keys []string
+	for _, e := range sections["embeddedFiles "+g.opts.Var] {
+		keys = append(keys, e.key)
+	}
+	return keys, nil
+}
+
+func withoutEntry(entries []entry, key string) []entry {
+	res := entries[:0]
+	for _, e := range entries {
+		if e.key != key {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Stats returns the sizes of the added files in the order of adding.
+// The file added later replaces the file with the same name.
+func (g *Generator) Stats() []Stats {
+	var res []Stats
+	index := map[string]int{}
+	for _, s := range g.stats {
+		if i, ok := index[s.Name]; ok {
+			res[i] = s
+			continue
+		}
+		index[s.Name] = len(res)
+		res = append(res, s)
+	}
+	return res
+}
+
+// WriteTo writes the generated file with the variable to w.
+func (g *Generator) WriteTo(w io.Writer) (int64, error) {
+	out, err := g.Update(nil)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(out)
+	return int64(n), err
+}
+
+// Update returns the src of the generated file with the variable.
+// The variable is added if the src does not contain it,
+// otherwise the files of the variable are replaced and the new files are added.
+// The variable generated with other options is generated again only with the added files.
+// The src is empty for the new file.
+// The variables generated with and without the development mode can not share the generated file,
+// because the build constraint of the development mode excludes the whole file.
+// With the custom template the whole file is generated by the template, so the src must be empty
+// or generated by the custom template of the variable.
+func (g *Generator) Update(src []byte) ([]byte, error) {
+	if g.tpl != nil {
+		return g.executeTemplate(src)
+	}
+	if bytes.Contains(src, []byte(templatePrefix)) {
+		return nil, errors.New("the file is generated by the custom template, the variable with the custom template needs own output file")
+	}
+
+	skeleton, sections, err := splitSections(src, g.sectionDecls())
+	if err != nil {
+		return nil, fmt.Errorf("failed parse generated file: %v", err)
+	}
+
+	if err := g.checkDevMode(skeleton, sections); err != nil {
+		return nil, err
+	}
+
+	skeleton, err = declareVar(skeleton, sections, embeddedFileTpl, embeddedVarTpl, g.fileConfig())
+	if err != nil {
+		return nil, err
+	}
+	if g.opts.Dev {
+		skeleton = addBuildConstraint(skeleton, prodBuildConstraint)
+	}
+
+	name := "embeddedFiles " + g.opts.Var
+	sections[name] = mergeEntries(sections[name], g.entries, g.removed)
+	if g.opts.Consts {
+		sections["embeddedConsts "+g.opts.Var] = g.consts(sections[name])
+	}
+	if g.opts.Metadata {
+		name = "embeddedMetadata " + g.opts.Var
+		sections[name] = mergeEntries(sections[name], g.metadata, g.removed)
+	}
+
+	// NOTE: gofmt aligns the constants and indents the patterns of the non-empty block only,
+	// so the constants are formatted with the skeleton
+	skeleton = joinSections(skeleton, takeSections(sections, "embeddedConsts "))
+	skeleton, err = gofmt(skeleton)
+	if err != nil {
+		return nil, err
+	}
+	return joinSections(skeleton, sections), nil
+}
+
+// consts returns the constants with all keys of the entries of the files including the keys added before.
+func (g *Generator) consts(files []entry) []entry {
+	keys := make([]string, len(files))
+	for i, e := range files {
+		keys[i] = e.key
+	}
+	return constEntries(g.opts.Var, keys)
+}
+
+// sectionDecls returns the declarations of the sections of the variable in the generated files.
+func (g *Generator) sectionDecls() sectionDecls {
+	private := unexported(g.opts.Var)
+	return sectionDecls{
+		vars: map[string]string{
+			g.opts.MapName():     "embeddedFiles " + g.opts.Var,
+			private + "Metadata": "embeddedMetadata " + g.opts.Var,
+			private + "Paths":    "embeddedPaths " + g.opts.Var,
+		},
+		consts: map[string]string{g.opts.Var + "Key": "embeddedConsts " + g.opts.Var},
+	}
+}
+
+func (g *Generator) fileConfig() embeddedFileConfig {
+	return embeddedFileConfig{
+		Package:   g.opts.Package,
+		FieldName: g.opts.Var,
+		Compress:  g.opts.Compress,
+		FS:        g.opts.FS,
+		Metadata:  g.opts.Metadata,
+		Accessors: g.opts.Accessors || g.opts.Immutable,
+		Immutable: g.opts.Immutable,
+		Consts:    g.opts.Consts,
+		Dev:       g.opts.Dev,
+	}
+}
+
+// gofmt returns the formatted source code with fixed imports.
+func gofmt(src []byte) ([]byte, error) {
+	src, err := fixImports(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed fix imports: %v", err)
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed formatting: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,423 @@
+package genembed
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"ok", Options{Package: "a", Var: "A"}, ""},
+		{"emptyVar", Options{Package: "a"}, "empty name of the variable"},
+		{"unknownCompression", Options{Package: "a", Var: "A", Compress: "lzma"}, `unknown compression method "lzma"`},
+		{"unknownEncoding", Options{Package: "a", Var: "A", Encoding: "hex"}, `unknown encoding "hex"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGenerator(tt.opts)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, g)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, g)
+		})
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	g, err := NewGenerator(Options{Package: "a", Var: "A", Metadata: true, ModTime: time.Unix(1, 0)})
+	require.NoError(t, err)
+	require.NoError(t, g.Add("f1", strings.NewReader("123")))
+	require.NoError(t, g.AddEntry(Entry{Name: "f2", Data: []byte("4\n5\n"), Mode: 0600, ModTime: time.Unix(2, 0), Encoding: EncodingString}))
+	require.EqualError(t, g.AddEntry(Entry{Name: "f3", Encoding: "hex"}), `unknown encoding "hex"`)
+	require.Error(t, g.Add("f4", errReader{}))
+
+	var buf bytes.Buffer
+	n, err := g.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+
+	src := buf.String()
+	formatted, err := format.Source(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, string(formatted), src)
+	require.Contains(t, src, "package a\n")
+	require.Contains(t, src, "\t\"f1\": []byte{\n\t\t0x31, 0x32, 0x33,\n\t},\n")
+	require.Contains(t, src, "\t\"f2\": []byte(`4\n5\n`),\n")
+	require.Contains(t, src, "ModTime: time.Unix(1, 0)")
+	require.Contains(t, src, "Mode: 0600, ModTime: time.Unix(2, 0)")
+	require.NotContains(t, src, `"f3"`)
+
+	t.Run("update", func(t *testing.T) {
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Metadata: true, ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("456")))
+		require.NoError(t, g.Add("f3", strings.NewReader("789")))
+		out, err := g.Update([]byte(src))
+		require.NoError(t, err)
+
+		g, err = NewGenerator(Options{Package: "a", Var: "B", Encoding: EncodingString})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		out, err = g.Update(out)
+		require.NoError(t, err)
+
+		got := string(out)
+		require.Contains(t, got, "\t\"f1\": []byte{\n\t\t0x34, 0x35, 0x36,\n\t},\n\t\"f2\": []byte(`4\n5\n`),\n\t\"f3\": []byte{")
+		require.Contains(t, got, "var B = map[string][]byte{\n")
+		require.Equal(t, 1, strings.Count(got, "package a\n"))
+
+		keys, err := g.Keys([]byte(src))
+		require.NoError(t, err)
+		require.Empty(t, keys)
+		g, err = NewGenerator(Options{Package: "a", Var: "A"})
+		require.NoError(t, err)
+		keys, err = g.Keys(out)
+		require.NoError(t, err)
+		require.Equal(t, []string{"f1", "f2", "f3"}, keys)
+
+		// the update does not modify the src
+		require.Equal(t, buf.String(), src)
+	})
+
+	t.Run("accessors", func(t *testing.T) {
+		for _, compress := range []string{"", "gzip"} {
+			g, err := NewGenerator(Options{Package: "a", Var: "A", Compress: compress, Accessors: true})
+			require.NoError(t, err)
+			require.NoError(t, g.Add("f1", strings.NewReader("123")))
+
+			var buf bytes.Buffer
+			_, err = g.WriteTo(&buf)
+			require.NoError(t, err)
+
+			got := buf.String()
+			require.Equal(t, 1, strings.Count(got, "func AGet(name string) ([]byte, error) {"))
+			require.Contains(t, got, "return nil, &ANotFoundError{Name: name}")
+			require.NotContains(t, got, "errors.New")
+			for _, fn := range []string{"AMustGet(name string) []byte", "ANames() []string", "AWalk(prefix string", "AOpen(name string) (io.ReadSeeker, error)"} {
+				require.Contains(t, got, "func "+fn)
+			}
+		}
+	})
+
+	t.Run("immutable", func(t *testing.T) {
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Immutable: true})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("123")))
+
+		var buf bytes.Buffer
+		_, err = g.WriteTo(&buf)
+		require.NoError(t, err)
+
+		got := buf.String()
+		require.Contains(t, got, "var aFiles = map[string][]byte{\n")
+		require.NotContains(t, got, "var A ")
+		require.Contains(t, got, "return aCopy(dat), nil")
+		require.Contains(t, got, "func AMustGet(name string) []byte")
+	})
+
+	t.Run("consts", func(t *testing.T) {
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Consts: true})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("index.html", strings.NewReader("1")))
+		require.NoError(t, g.Add("css/app.min.css", strings.NewReader("2")))
+
+		var buf bytes.Buffer
+		_, err = g.WriteTo(&buf)
+		require.NoError(t, err)
+
+		g, err = NewGenerator(Options{Package: "a", Var: "A", Consts: true})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("css/app-min.css", strings.NewReader("3")))
+		out, err := g.Update(buf.Bytes())
+		require.NoError(t, err)
+
+		got := string(out)
+		require.Contains(t, got, "\tAKeyCssAppMinCss  = \"css/app-min.css\"\n\tAKeyCssAppMinCss2 = \"css/app.min.css\"\n\tAKeyIndexHtml     = \"index.html\"\n")
+		formatted, err := format.Source(out)
+		require.NoError(t, err)
+		require.Equal(t, got, string(formatted))
+
+		// the update with the same files does not change the file
+		again, err := g.Update(out)
+		require.NoError(t, err)
+		require.Equal(t, got, string(again))
+	})
+
+	t.Run("options", func(t *testing.T) {
+		g, err := NewGenerator(Options{Package: "a", Var: "A"})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		require.NoError(t, g.Add("f2", strings.NewReader("2")))
+		plain, err := g.Update(nil)
+		require.NoError(t, err)
+
+		g, err = NewGenerator(Options{Package: "a", Var: "B"})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		plain, err = g.Update(plain)
+		require.NoError(t, err)
+
+		// the variable generated with other options is generated again only with the added files
+		g, err = NewGenerator(Options{Package: "a", Var: "A", Compress: "gzip", Accessors: true})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		out, err := g.Update(plain)
+		require.NoError(t, err)
+
+		got := string(out)
+		require.Contains(t, got, "// A list of embedded files compressed with gzip.\n")
+		require.Equal(t, 1, strings.Count(got, "var A = map[string][]byte{"))
+		require.Contains(t, got, "func AGet(name string) ([]byte, error) {")
+		require.Contains(t, got, "func AMustGet(name string) []byte {")
+		require.NotContains(t, got, `"f2"`)
+		require.Contains(t, got, "var B = map[string][]byte{\n\t// [START embeddedFiles B]\n\t\"f1\": []byte{")
+		require.Less(t, strings.Index(got, "var A "), strings.Index(got, "var B "))
+
+		again, err := g.Update(out)
+		require.NoError(t, err)
+		require.Equal(t, got, string(again))
+
+		g, err = NewGenerator(Options{Package: "a", Var: "A", Immutable: true, Metadata: true})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		out, err = g.Update(out)
+		require.NoError(t, err)
+
+		got = string(out)
+		require.NotContains(t, got, "var A ")
+		require.NotContains(t, got, "gzip")
+		require.Contains(t, got, "var aFiles = map[string][]byte{\n\t// [START embeddedFiles A]\n\t\"f1\": []byte{")
+		require.Contains(t, got, "var aMetadata = map[string]AMetadata{\n\t// [START embeddedMetadata A]\n\t\"f1\": {")
+		formatted, err := format.Source(out)
+		require.NoError(t, err)
+		require.Equal(t, got, string(formatted))
+
+		g, err = NewGenerator(Options{Package: "a", Var: "A"})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		out, err = g.Update(out)
+		require.NoError(t, err)
+
+		got = string(out)
+		require.Contains(t, got, "// A list of embedded files.\nvar A = map[string][]byte{\n\t// [START embeddedFiles A]\n\t\"f1\": []byte{")
+		for _, name := range []string{"aFiles", "aMetadata", "AMetadata", "AInfo", "AGet", "aCopy", "import"} {
+			require.NotContains(t, got, name)
+		}
+	})
+
+	t.Run("metadataOption", func(t *testing.T) {
+		// the metadata of the existing file is removed without the option
+		g, err := NewGenerator(Options{Package: "a", Var: "A", FS: true, Consts: true})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("456")))
+		out, err := g.Update([]byte(src))
+		require.NoError(t, err)
+
+		got := string(out)
+		for _, name := range []string{"aMetadata", "AMetadata", "AInfo", "0x31, 0x32, 0x33"} {
+			require.NotContains(t, got, name)
+		}
+		require.Contains(t, got, "func AFS() http.FileSystem {")
+		require.Contains(t, got, "\tAKeyF1 = \"f1\"\n")
+
+		// the metadata is generated for the files of the existing file with the option
+		g, err = NewGenerator(Options{Package: "a", Var: "A", FS: true, Consts: true, Metadata: true, ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("456")))
+		out, err = g.Update(out)
+		require.NoError(t, err)
+
+		got = string(out)
+		require.Contains(t, got, "var aMetadata = map[string]AMetadata{\n\t// [START embeddedMetadata A]\n\t\"f1\": {\n\t\tSize: 3, ")
+		require.Contains(t, got, "mode: aMetadata[key].Mode")
+	})
+
+	t.Run("editedFile", func(t *testing.T) {
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Metadata: true, Consts: true, ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		require.NoError(t, g.Add("f2", strings.NewReader("2")))
+		require.NoError(t, g.Add("f3", strings.NewReader("3")))
+		want, err := g.Update(nil)
+		require.NoError(t, err)
+
+		// the patterns are removed and the entries are reformatted
+		var lines []string
+		for _, line := range strings.SplitAfter(string(want), "\n") {
+			if !strings.Contains(line, "// [START") && !strings.Contains(line, "// [END") {
+				lines = append(lines, strings.Replace(line, "\t", "    ", -1))
+			}
+		}
+		edited := strings.Join(lines, "")
+		edited = strings.Replace(edited, "[]byte{\n        0x31,\n    }", "[]byte{0x31}", 1)
+
+		g, err = NewGenerator(Options{Package: "a", Var: "A", Metadata: true, Consts: true, ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f2", strings.NewReader("2")))
+		require.NoError(t, g.Add("f4", strings.NewReader("4")))
+		g.Remove("f3")
+		g.Remove("f4")
+		out, err := g.Update([]byte(edited))
+		require.NoError(t, err)
+
+		got := string(out)
+		require.Equal(t, 1, strings.Count(got, "// [START embeddedFiles A]\n"))
+		require.Equal(t, 1, strings.Count(got, "var A = map[string][]byte{"))
+		require.Contains(t, got, "\t\"f1\": []byte{0x31},\n\t\"f2\": []byte{")
+		require.NotContains(t, got, `"f3"`)
+		require.NotContains(t, got, `"f4"`)
+		require.Contains(t, got, "\tAKeyF1 = \"f1\"\n\tAKeyF2 = \"f2\"\n")
+		formatted, err := format.Source(out)
+		require.NoError(t, err)
+		require.Equal(t, got, string(formatted))
+
+		g, err = NewGenerator(Options{Package: "a", Var: "A", Metadata: true, Consts: true, ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f3", strings.NewReader("3")))
+		g.Remove("f1")
+		out, err = g.Update(out)
+		require.NoError(t, err)
+		g, err = NewGenerator(Options{Package: "a", Var: "A", Metadata: true, Consts: true, ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("1")))
+		require.Len(t, g.Stats(), 1)
+		out, err = g.Update(out)
+		require.NoError(t, err)
+		require.Contains(t, string(out), "\t\"f2\": []byte{\n\t\t0x32,\n\t},\n\t\"f3\": []byte{\n\t\t0x33,\n\t},\n\t\"f1\": []byte{")
+	})
+
+	t.Run("template", func(t *testing.T) {
+		const tpl = `// Code generated by custom template. DO NOT EDIT.
+
+package {{.Package}}
+
+// {{.Var}} files compressed with "{{.Compress}}".
+var {{.Var}} = []struct {
+	Name string
+	Hash string
+	Size int64
+	Data []byte
+}{
+{{- range .Entries }}
+	{ {{ quote .Name }}, "{{.Hash}}", {{.Size}}, {{ literal . }} },
+{{- end }}
+}
+
+var {{ unexported .Var }}Text = {{ string (index .Entries 0).Bytes }}
+var {{ unexported .Var }}Base64 = "{{ base64 (index .Entries 0).Bytes }}"
+var {{ unexported .Var }}Bytes = {{ bytes (index .Entries 0).Bytes }}
+`
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Template: tpl})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f2", strings.NewReader("2")))
+		require.NoError(t, g.AddEntry(Entry{Name: "f1", Data: []byte("1\n2"), Encoding: EncodingString}))
+		require.NoError(t, g.Add("f3", strings.NewReader("3")))
+		g.Remove("f3")
+
+		_, err = g.Update([]byte("package a\n\nvar B = 1\n"))
+		require.EqualError(t, err, "the file is not generated by the custom template of A, the variable with the custom template needs own output file")
+		out, err := g.Update(nil)
+		require.NoError(t, err)
+		require.Equal(t, `// Code generated by custom template. DO NOT EDIT.
+
+package a
+
+// A files compressed with "".
+var A = []struct {
+	Name string
+	Hash string
+	Size int64
+	Data []byte
+}{
+	{"f1", "b598b3a62a3f7cedb17e66d1cb31d53dffeebaf5c07e2c60d5e31971936fd35e", 3, []byte(`+"`1\n2`"+`)},
+	{"f2", "d4735e3a265e16eee03f59718b9b5d03019c07d8b6c51f90da3a666eec13ab35", 1, []byte{
+		0x32,
+	}},
+}
+
+var aText = `+"`1\n2`"+`
+var aBase64 = "MQoy"
+var aBytes = []byte{
+	0x31, 0xa, 0x32,
+}
+
+// [TEMPLATE A]
+`, string(out))
+
+		// the file generated by the template is replaced by the template of the same variable only
+		again, err := g.Update(out)
+		require.NoError(t, err)
+		require.Equal(t, string(out), string(again))
+		other, err := NewGenerator(Options{Package: "a", Var: "B", Template: tpl})
+		require.NoError(t, err)
+		require.NoError(t, other.Add("f1", strings.NewReader("1")))
+		_, err = other.Update(out)
+		require.EqualError(t, err, "the file is not generated by the custom template of B, the variable with the custom template needs own output file")
+		other, err = NewGenerator(Options{Package: "a", Var: "B"})
+		require.NoError(t, err)
+		_, err = other.Update(out)
+		require.EqualError(t, err, "the file is generated by the custom template, the variable with the custom template needs own output file")
+
+		_, err = NewGenerator(Options{Package: "a", Var: "A", Template: "{{ .Var"})
+		require.Error(t, err)
+		_, err = NewGenerator(Options{Package: "a", Var: "A", Template: tpl, Dev: true})
+		require.EqualError(t, err, "development mode is not supported with the custom template")
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Compress: "gzip"})
+		require.NoError(t, err)
+		require.NoError(t, g.Add("f1", strings.NewReader("123")))
+		require.NoError(t, g.Add("f2", strings.NewReader(strings.Repeat("4", 1000))))
+		require.NoError(t, g.Add("f1", strings.NewReader("56")))
+
+		stats := g.Stats()
+		require.Len(t, stats, 2)
+		require.Equal(t, "f1", stats[0].Name)
+		require.Equal(t, int64(2), stats[0].Size)
+		require.Equal(t, "f2", stats[1].Name)
+		require.Equal(t, int64(1000), stats[1].Size)
+		require.True(t, stats[1].CompressedSize < 100)
+		require.True(t, stats[1].EncodedSize > stats[1].CompressedSize)
+	})
+
+	t.Run("addFile", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "genembed")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		filename := filepath.Join(dir, "f1")
+		require.NoError(t, ioutil.WriteFile(filename, []byte("123"), 0600))
+		require.NoError(t, os.Chtimes(filename, time.Unix(5, 0), time.Unix(5, 0)))
+
+		g, err := NewGenerator(Options{Package: "a", Var: "A", Metadata: true})
+		require.NoError(t, err)
+		require.NoError(t, g.AddFile("f1", filename))
+		require.Error(t, g.AddFile("f2", filepath.Join(dir, "f2")))
+
+		var buf bytes.Buffer
+		_, err = g.WriteTo(&buf)
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "Size: 3, Mode: 0600, ModTime: time.Unix(5, 0)")
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read error") }
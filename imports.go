@@ -0,0 +1,74 @@
+package genembed
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// knownImports list of packages that can be used by the generated code.
+var knownImports = map[string]string{
+	"bytes":    "bytes",
+	"errors":   "errors",
+	"filepath": "path/filepath",
+	"hex":      "encoding/hex",
+	"flate":    "compress/flate",
+	"gzip":     "compress/gzip",
+	"http":     "net/http",
+	"io":       "io",
+	"ioutil":   "io/ioutil",
+	"os":       "os",
+	"path":     "path",
+	"runtime":  "runtime",
+	"sha256":   "crypto/sha256",
+	"sort":     "sort",
+	"strings":  "strings",
+	"sync":     "sync",
+	"time":     "time",
+	"zlib":     "compress/zlib",
+}
+
+// fixImports replaces the imports of the generated file by the packages used in the code.
+func fixImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	for _, ident := range f.Unresolved {
+		if path, ok := knownImports[ident.Name]; ok {
+			used[path] = true
+		}
+	}
+	var paths []string
+	for path := range used {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// NOTE: the generated file contains only one import declaration (or nothing) right after the package clause
+	start := fset.Position(f.Name.End()).Offset
+	end := start
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			end = fset.Position(gen.End()).Offset
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(src[:start])
+	if len(paths) > 0 {
+		buf.WriteString("\n\nimport (\n")
+		for _, path := range paths {
+			buf.WriteString(strconv.Quote(path) + "\n")
+		}
+		buf.WriteString(")")
+	}
+	buf.Write(src[end:])
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package genembed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fixImports(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"noImports",
+			"package a\n\nvar A = 1\n",
+			"package a\n\nvar A = 1\n",
+		},
+		{
+			"addImports",
+			"package a\n\nvar A = bytes.NewReader(nil)\nvar B = errors.New(\"\")\n",
+			"package a\n\nimport (\n\"bytes\"\n\"errors\"\n)\n\nvar A = bytes.NewReader(nil)\nvar B = errors.New(\"\")\n",
+		},
+		{
+			"replaceImports",
+			"package a\n\nimport (\n\"sync\"\n\"errors\"\n)\n\nvar A = gzip.NewReader\n",
+			"package a\n\nimport (\n\"compress/gzip\"\n)\n\nvar A = gzip.NewReader\n",
+		},
+		{
+			"removeImports",
+			"package a\n\nimport \"sync\"\n\nvar A = 1\n",
+			"package a\n\nvar A = 1\n",
+		},
+		{
+			"unknownPackage",
+			"package a\n\nvar A = foo.Bar\n",
+			"package a\n\nvar A = foo.Bar\n",
+		},
+		{
+			"declared",
+			"package a\n\nvar bytes = 1\nvar A = bytes\n",
+			"package a\n\nvar bytes = 1\nvar A = bytes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fixImports([]byte(tt.in))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := fixImports([]byte("package"))
+		require.Error(t, err)
+	})
+}
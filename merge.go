@@ -0,0 +1,445 @@
+package genembed
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NOTE: formatting of the whole generated file takes seconds for several megabytes of the embedded files,
+// so only the code without entries (skeleton) is formatted, the entries are written already formatted.
+
+// entry of the map in the generated file.
+type entry struct {
+	key string
+	src []byte // formatted source code of the key and the value with trailing comma and line break
+}
+
+// sectionDecls are the declarations of the sections of the variable, the declarations are found
+// by the names even if the patterns are removed or moved by an editor.
+type sectionDecls struct {
+	vars   map[string]string // section by the name of the variable declared with the map literal
+	consts map[string]string // section by the common prefix of the names of the constants
+}
+
+// patternRe matches the start and end patterns of the sections.
+var patternRe = regexp.MustCompile(`^// \[(START|END) (.+)\]$`)
+
+// splitSections returns the src without the entries of the sections and the entries by the name of the section
+// (the text of the pattern after START). The declarations of the sections in the returned src contain only
+// the start and end patterns.
+//
+// The declarations of the sections are found in the syntax tree of the src by the names of the decls
+// or by the patterns inside them. The entries outside the patterns or not formatted by genembed
+// are taken from the syntax tree, so the src may be formatted or edited. Only the src without
+// the entries between the patterns is parsed, so the parsing does not depend on the size of the files.
+func splitSections(src []byte, decls sectionDecls) ([]byte, map[string][]entry, error) {
+	if len(bytes.TrimSpace(src)) == 0 {
+		return nil, map[string][]entry{}, nil
+	}
+	src, elided := elideSections(src)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	file := fset.File(f.Pos())
+
+	type pattern struct {
+		start  bool
+		name   string
+		pos    int
+		end    int
+		inside bool // inside the declaration of the section
+	}
+	var patterns []*pattern
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			if m := patternRe.FindStringSubmatch(c.Text); m != nil {
+				patterns = append(patterns, &pattern{m[1] == "START", m[2], file.Offset(c.Pos()), file.Offset(c.End()), false})
+			}
+		}
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	sections := map[string][]entry{}
+	addSection := func(name string, open, close token.Pos, elts []ast.Node) error {
+		begin, end := file.Offset(open)+1, file.Offset(close)
+		var entries []entry
+		for _, p := range patterns {
+			if p.pos <= begin || p.pos >= end {
+				continue
+			}
+			p.inside = true
+			if p.start && name == "" {
+				name = p.name
+			}
+		}
+		if name == "" {
+			return nil
+		}
+		if _, ok := sections[name]; ok {
+			return fmt.Errorf("duplicate declaration of the section %s", name)
+		}
+
+		// NOTE: the entries and the elided entries after the start patterns are taken in the order of the src
+		i := 0
+		for _, p := range patterns {
+			if p.pos <= begin || p.pos >= end {
+				continue
+			}
+			for ; i < len(elts) && file.Offset(elts[i].Pos()) < p.pos; i++ {
+				e, err := nodeEntry(fset, src, elts[i])
+				if err != nil {
+					return err
+				}
+				entries = append(entries, e)
+			}
+			if p.start {
+				entries = append(entries, elided[p.name]...)
+				delete(elided, p.name)
+			}
+		}
+		for ; i < len(elts); i++ {
+			e, err := nodeEntry(fset, src, elts[i])
+			if err != nil {
+				return err
+			}
+			entries = append(entries, e)
+		}
+
+		sections[name] = entries
+		edits = append(edits, edit{begin, end, "\n\t" + "// [START " + name + "]\n\t" + "// [END " + name + "]\n"})
+		return nil
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		switch gen.Tok {
+		case token.VAR:
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if len(spec.Names) != 1 || len(spec.Values) != 1 {
+					continue
+				}
+				lit, ok := spec.Values[0].(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				if _, ok := lit.Type.(*ast.MapType); !ok {
+					continue
+				}
+				elts := make([]ast.Node, len(lit.Elts))
+				for i, elt := range lit.Elts {
+					elts[i] = elt
+				}
+				if err := addSection(decls.vars[spec.Names[0].Name], lit.Lbrace, lit.Rbrace, elts); err != nil {
+					return nil, nil, err
+				}
+			}
+		case token.CONST:
+			if !gen.Lparen.IsValid() {
+				continue
+			}
+			var name string
+			elts := make([]ast.Node, len(gen.Specs))
+			for i, spec := range gen.Specs {
+				elts[i] = spec
+				section := constsSection(decls, spec.(*ast.ValueSpec))
+				if i == 0 {
+					name = section
+				} else if section != name {
+					name = ""
+				}
+			}
+			if err := addSection(name, gen.Lparen, gen.Rparen, elts); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for name := range elided {
+		return nil, nil, fmt.Errorf("section %s is outside of the declaration of the variable", name)
+	}
+
+	// NOTE: the patterns outside the declarations are removed, so the entries are not placed after them
+	for _, p := range patterns {
+		if p.inside {
+			continue
+		}
+		start, end := p.pos, p.end
+		lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+		if len(bytes.TrimSpace(src[lineStart:start])) == 0 && end < len(src) && src[end] == '\n' {
+			start, end = lineStart, end+1
+		}
+		edits = append(edits, edit{start, end, ""})
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	skeleton := make([]byte, 0, len(src))
+	last := 0
+	for _, e := range edits {
+		skeleton = append(skeleton, src[last:e.start]...)
+		skeleton = append(skeleton, e.text...)
+		last = e.end
+	}
+	return append(skeleton, src[last:]...), sections, nil
+}
+
+// constsSection returns the section of the constant by the prefix of its name.
+func constsSection(decls sectionDecls, spec *ast.ValueSpec) string {
+	for prefix, section := range decls.consts {
+		if len(spec.Names) == 1 && strings.HasPrefix(spec.Names[0].Name, prefix) {
+			return section
+		}
+	}
+	return ""
+}
+
+// elideSections returns the src without the entries between the start and end patterns
+// and the entries by the name of the section. The entries not formatted by genembed are not elided.
+func elideSections(src []byte) ([]byte, map[string][]entry) {
+	const start, end = "// [START ", "// [END "
+
+	skeleton := make([]byte, 0, len(src))
+	elided := map[string][]entry{}
+	for {
+		i := bytes.Index(src, []byte(start))
+		if i < 0 {
+			break
+		}
+		lineEnd := bytes.IndexByte(src[i:], '\n')
+		if lineEnd < 0 {
+			break
+		}
+		lineEnd += i + 1
+		name := string(bytes.TrimSuffix(bytes.TrimSpace(src[i+len(start):lineEnd]), []byte("]")))
+
+		skeleton = append(skeleton, src[:lineEnd]...)
+		src = src[lineEnd:]
+
+		j := bytes.Index(src, []byte(end+name+"]"))
+		if j < 0 {
+			continue
+		}
+		bodyEnd := bytes.LastIndexByte(src[:j], '\n') + 1
+		entries, err := parseEntries(src[:bodyEnd])
+		if err != nil {
+			continue
+		}
+		elided[name] = append(elided[name], entries...)
+		src = src[bodyEnd:]
+	}
+	return append(skeleton, src...), elided
+}
+
+// nodeEntry returns the entry of the element of the map literal or the constant specification.
+// The source code of the entry formatted by genembed is taken as is, otherwise the entry is printed
+// as gofmt prints it.
+func nodeEntry(fset *token.FileSet, src []byte, node ast.Node) (entry, error) {
+	var key string
+	switch node := node.(type) {
+	case *ast.KeyValueExpr:
+		lit, ok := node.Key.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return entry{}, fmt.Errorf("unexpected key of the entry at %s", fset.Position(node.Pos()))
+		}
+		var err error
+		if key, err = strconv.Unquote(lit.Value); err != nil {
+			return entry{}, fmt.Errorf("invalid key of the entry at %s: %v", fset.Position(node.Pos()), err)
+		}
+
+		file := fset.File(node.Pos())
+		start, end := file.Offset(node.Pos()), file.Offset(node.End())
+		start = bytes.LastIndexByte(src[:start], '\n') + 1
+		if end < len(src) && src[end] == ',' {
+			end++
+		}
+		if end < len(src) && src[end] == '\n' {
+			end++
+		}
+		if entries, err := parseEntries(src[start:end]); err == nil && len(entries) == 1 && entries[0].key == key {
+			return entries[0], nil
+		}
+	case *ast.ValueSpec:
+		key = node.Names[0].Name
+	default:
+		return entry{}, fmt.Errorf("unexpected entry at %s", fset.Position(node.Pos()))
+	}
+
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8, Indent: 1}
+	if err := cfg.Fprint(&buf, fset, node); err != nil {
+		return entry{}, err
+	}
+	if _, ok := node.(*ast.KeyValueExpr); ok {
+		buf.WriteByte(',')
+	}
+	buf.WriteByte('\n')
+	return entry{key, buf.Bytes()}, nil
+}
+
+// joinSections returns the skeleton with the entries placed after the start patterns.
+func joinSections(skeleton []byte, sections map[string][]entry) []byte {
+	const start = "// [START "
+
+	size := len(skeleton)
+	for _, entries := range sections {
+		for _, e := range entries {
+			size += len(e.src)
+		}
+	}
+	out := make([]byte, 0, size)
+	for {
+		i := bytes.Index(skeleton, []byte(start))
+		if i < 0 {
+			break
+		}
+		lineEnd := bytes.IndexByte(skeleton[i:], '\n') + i + 1
+		name := string(bytes.TrimSuffix(bytes.TrimSpace(skeleton[i+len(start):lineEnd]), []byte("]")))
+
+		out = append(out, skeleton[:lineEnd]...)
+		for _, e := range sections[name] {
+			out = append(out, e.src...)
+		}
+		skeleton = skeleton[lineEnd:]
+	}
+	return append(out, skeleton...)
+}
+
+// takeSections removes the sections with the prefix of the name from the sections and returns them.
+func takeSections(sections map[string][]entry, prefix string) map[string][]entry {
+	res := map[string][]entry{}
+	for name, entries := range sections {
+		if strings.HasPrefix(name, prefix) {
+			res[name] = entries
+			delete(sections, name)
+		}
+	}
+	return res
+}
+
+// mergeEntries returns the existing entries of the section with the new entries and without the removed entries.
+// The existing entries with the same keys are replaced in place, other entries are added to the end.
+func mergeEntries(existing, entries []entry, removed map[string]bool) []entry {
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		index[e.key] = i
+	}
+	added := make([]bool, len(entries))
+
+	out := make([]entry, 0, len(existing)+len(entries))
+	for _, e := range existing {
+		if i, ok := index[e.key]; ok {
+			if !added[i] {
+				out = append(out, entries[i])
+				added[i] = true
+			}
+			continue
+		}
+		if !removed[e.key] {
+			out = append(out, e)
+		}
+	}
+	for i, e := range entries {
+		// NOTE: the last entry wins if the keys are duplicated
+		if !added[i] && index[e.key] == i {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// entryLayouts are the beginnings of the values of the entries and the ends of the entries written by genembed.
+var entryLayouts = []struct {
+	begin, end string
+}{
+	{"[]byte{\n", "\n\t},\n"},
+	{"[]byte(\n", "\n\t),\n"},
+	// NOTE: the raw string does not contain the backquotes
+	{"[]byte(`", "`),\n"},
+	{"{\n", "\n\t},\n"}, // metadata
+	{"\"", "\",\n"},      // path of the file in the development mode
+}
+
+// parseEntries returns the entries from the formatted source code between the patterns.
+// The entries are written by genembed, so the end of the entry is found by the beginning of its value
+// without parsing of the whole value.
+func parseEntries(body []byte) ([]entry, error) {
+	var entries []entry
+	for len(body) > 0 {
+		if body[0] == '\n' {
+			body = body[1:]
+			continue
+		}
+
+		n := quotedLen(body)
+		if n < 0 {
+			return nil, fmt.Errorf("unexpected entry %.40q", body)
+		}
+		key, err := strconv.Unquote(string(body[1:n]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key of the entry %.40q: %v", body, err)
+		}
+
+		// NOTE: gofmt aligns the values of the single-line entries
+		value := n
+		if value < len(body) && body[value] == ':' {
+			value++
+			for value < len(body) && body[value] == ' ' {
+				value++
+			}
+		}
+
+		size := -1
+		for _, layout := range entryLayouts {
+			if value == n || !bytes.HasPrefix(body[value:], []byte(layout.begin)) {
+				continue
+			}
+			if i := bytes.Index(body[value+len(layout.begin):], []byte(layout.end)); i >= 0 {
+				size = value + len(layout.begin) + i + len(layout.end)
+			}
+			break
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("unexpected entry %q", key)
+		}
+
+		entries = append(entries, entry{key, body[:size]})
+		body = body[size:]
+	}
+	return entries, nil
+}
+
+// quotedLen returns the length of the indent and the interpreted string literal at the beginning of the line,
+// -1 if the line does not start with the string literal.
+func quotedLen(line []byte) int {
+	if !bytes.HasPrefix(line, []byte("\t\"")) {
+		return -1
+	}
+	for i := 2; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,175 @@
+package genembed
+
+import (
+	"go/format"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mergeEntries(t *testing.T) {
+	entryOf := func(key, value string) entry {
+		return entry{key, embeddedEntry(key, []byte(value), "string")}
+	}
+
+	tests := []struct {
+		name     string
+		existing []entry
+		entries  []entry
+		removed  []string
+		want     []entry
+	}{
+		{"empty", nil, []entry{entryOf("a", "1")}, nil, []entry{entryOf("a", "1")}},
+		{"add", []entry{entryOf("a", "1")}, []entry{entryOf("b", "2")}, nil, []entry{entryOf("a", "1"), entryOf("b", "2")}},
+		{
+			"replaceInPlace",
+			[]entry{entryOf("a", "1"), entryOf("b", "2"), entryOf("c", "3")},
+			[]entry{entryOf("d", "4"), entryOf("b", "5\n5")},
+			nil,
+			[]entry{entryOf("a", "1"), entryOf("b", "5\n5"), entryOf("c", "3"), entryOf("d", "4")},
+		},
+		{"duplicates", nil, []entry{entryOf("a", "1"), entryOf("a", "2")}, nil, []entry{entryOf("a", "2")}},
+		{
+			"remove",
+			[]entry{entryOf("a", "1"), entryOf("b", "2"), entryOf("c", "3")},
+			[]entry{entryOf("c", "4")},
+			[]string{"a", "d"},
+			[]entry{entryOf("b", "2"), entryOf("c", "4")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed := map[string]bool{}
+			for _, key := range tt.removed {
+				removed[key] = true
+			}
+			require.Equal(t, tt.want, mergeEntries(tt.existing, tt.entries, removed))
+		})
+	}
+}
+
+func Test_parseEntries(t *testing.T) {
+	_, err := parseEntries([]byte("\t\"a\": nil,\n"))
+	require.EqualError(t, err, `unexpected entry "a"`)
+}
+
+func Test_splitSections(t *testing.T) {
+	decls := sectionDecls{
+		vars:   map[string]string{"A": "embeddedFiles A", "aMetadata": "embeddedMetadata A"},
+		consts: map[string]string{"AKey": "embeddedConsts A"},
+	}
+	entryOf := func(key, value string) entry {
+		return entry{key, embeddedEntry(key, []byte(value), "string")}
+	}
+	skeleton := "package a\n\n" +
+		"var A = map[string][]byte{\n\t// [START embeddedFiles A]\n\t// [END embeddedFiles A]\n}\n\n" +
+		"var B = map[string][]byte{\n\t// [START embeddedFiles B]\n\t// [END embeddedFiles B]\n}\n"
+
+	tests := []struct {
+		name         string
+		src          string
+		wantSkeleton string
+		want         map[string][]entry
+		wantErr      string
+	}{
+		{"empty", "", "", map[string][]entry{}, ""},
+		{
+			"patterns",
+			"package a\n\nvar A = map[string][]byte{\n\t// [START embeddedFiles A]\n" + string(entryOf("a", "1").src) + string(entryOf("b", "2\n").src) + "\t// [END embeddedFiles A]\n}\n\n" +
+				"var B = map[string][]byte{\n\t// [START embeddedFiles B]\n" + string(entryOf("a", "3").src) + "\t// [END embeddedFiles B]\n}\n",
+			skeleton,
+			map[string][]entry{"embeddedFiles A": {entryOf("a", "1"), entryOf("b", "2\n")}, "embeddedFiles B": {entryOf("a", "3")}},
+			"",
+		},
+		{
+			"movedPatterns",
+			"package a\n\n// [START embeddedFiles A]\nvar A = map[string][]byte{\n" + string(entryOf("a", "1").src) + "\t// [END embeddedFiles A]\n" + string(entryOf("b", "2").src) + "}\n\n" +
+				"var B = map[string][]byte{\n" + string(entryOf("a", "3").src) + "\t// [START embeddedFiles B]\n\t// [END embeddedFiles B]\n}\n",
+			skeleton,
+			map[string][]entry{"embeddedFiles A": {entryOf("a", "1"), entryOf("b", "2")}, "embeddedFiles B": {entryOf("a", "3")}},
+			"",
+		},
+		{
+			"formatted",
+			"package a\n\nvar A = map[string][]byte{\"a\": []byte(\"1\"),\n    \"b\": []byte{0x32,\n0x33}}\n\n" +
+				"var B = map[string][]byte{\n\t// [START embeddedFiles B]\n\t// [END embeddedFiles B]\n}\n",
+			skeleton,
+			map[string][]entry{
+				"embeddedFiles A": {{"a", []byte("\t\"a\": []byte(\"1\"),\n")}, {"b", []byte("\t\"b\": []byte{0x32,\n\t\t0x33},\n")}},
+				"embeddedFiles B": nil,
+			},
+			"",
+		},
+		{
+			"consts",
+			"package a\n\nconst (\n\tAKeyA  = \"a\"\n\tAKeyBc = \"b/c\"\n)\n\nconst (\n\tX = \"x\"\n)\n",
+			"package a\n\nconst (\n\t// [START embeddedConsts A]\n\t// [END embeddedConsts A]\n)\n\nconst (\n\tX = \"x\"\n)\n",
+			map[string][]entry{"embeddedConsts A": {{"AKeyA", []byte("\tAKeyA = \"a\"\n")}, {"AKeyBc", []byte("\tAKeyBc = \"b/c\"\n")}}},
+			"",
+		},
+		{
+			"duplicate",
+			"package a\n\nvar A = map[string][]byte{}\n\nvar B = map[string][]byte{\n\t// [START embeddedFiles A]\n\t// [END embeddedFiles A]\n}\n",
+			"",
+			nil,
+			"duplicate declaration of the section embeddedFiles A",
+		},
+		{"invalid", "package a\n\nvar A = map[string][]byte{\n", "", nil, "expected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, sections, err := splitSections([]byte(tt.src), decls)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSkeleton, string(got))
+			require.Equal(t, tt.want, sections)
+		})
+	}
+}
+
+// Test_formattedEntries checks that the entries are written as gofmt formats them
+// and can be read back.
+func Test_formattedEntries(t *testing.T) {
+	var body, metadata []byte
+	var keys []string
+	for _, tt := range []struct {
+		key, value, compress, encoding string
+	}{
+		{"bytes", "123456789012345678901234567890", "", "bytes"},
+		{"string", "123", "", "string"},
+		{"raw", "1\n2\n", "", "string"},
+		{"quoted", "1`\n", "", "string"},
+		{"empty", "", "", "bytes"},
+		{"compressed", "123", "gzip", "bytes"},
+		{"compressedString", "123", "gzip", "string"},
+		{"key\n\"with\" special\tchars", "1", "", "bytes"},
+	} {
+		dat, err := compress(tt.compress, []byte(tt.value))
+		require.NoError(t, err)
+		e := embeddedEntry(tt.key, dat, tt.encoding)
+		body = append(body, e...)
+		metadata = append(metadata, metadataEntry(tt.key, []byte(tt.value), 0644, time.Unix(1, 2))...)
+		keys = append(keys, tt.key)
+	}
+
+	src := "package a\n\nvar A = map[string][]byte{\n" + string(body) + "}\n\n" +
+		"var B = map[string]M{\n" + string(metadata) + "}\n"
+	want, err := format.Source([]byte(src))
+	require.NoError(t, err)
+	require.Equal(t, string(want), src)
+
+	for _, body := range [][]byte{body, metadata} {
+		entries, err := parseEntries(body)
+		require.NoError(t, err)
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.key)
+		}
+		require.Equal(t, keys, got)
+	}
+}
@@ -0,0 +1,424 @@
+package genembed
+
+import (
+	"text/template"
+)
+
+type embeddedFileConfig struct {
+	Package   string
+	FieldName string
+	Compress  string // compression method, empty if the files are not compressed
+	FS        bool   // generate http.FileSystem
+	Metadata  bool   // generate metadata table
+	Accessors bool   // generate accessor functions
+	Immutable bool   // the map is unexported, the accessors return the copies of the content
+	Consts    bool   // generate constants with the keys
+	Dev       bool   // generate the development file
+	DevFile   bool   // the template of the development file is executed
+}
+
+var embeddedFileTpl = template.Must(template.New("_genembed.go").Parse(`// Code generated by github.com/gebv/go-embed. DO NOT EDIT.
+{{ if .Dev }}
+` + prodBuildConstraint + `
+
+{{ end }}package {{.Package}}
+`))
+
+// embeddedVarTpl is the template of the variable with embedded files.
+// Each variable has own start and end patterns, the files are added between them.
+var embeddedVarTpl = template.Must(template.New("var").Funcs(template.FuncMap{
+	"startPattern": startPattern,
+	"endPattern":   endPattern,
+	"unexported":   unexported,
+}).Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- if .Immutable }}
+// {{$map}} list of embedded files{{ if .Compress }} compressed with {{.Compress}}{{ end }}.
+// Use {{$name}}Get to get the copy of the content.
+{{- else if .Compress }}
+// {{$name}} list of embedded files compressed with {{.Compress}}.
+// Use {{$name}}Get to get the decompressed content.
+{{- else }}
+// {{$name}} list of embedded files.
+{{- end }}
+var {{$map}} = map[string][]byte{
+	{{startPattern "embeddedFiles" $name}}
+	{{endPattern "embeddedFiles" $name}}
+}
+{{- if .Consts }}
+{{ template "consts" . }}
+{{- end }}
+{{- if .Metadata }}
+{{ template "metadata" . }}
+{{- end }}
+{{- if .Compress }}
+{{ template "compress" . }}
+{{- end }}
+{{- if .Accessors }}
+{{ template "accessors" . }}
+{{- end }}
+{{- if .FS }}
+{{ template "fs" . }}
+{{- end }}
+`))
+
+var constsTpl = template.Must(embeddedVarTpl.New("consts").Parse(`
+{{- $name := .FieldName }}
+// Keys of the embedded files of {{$name}}.
+const (
+	{{startPattern "embeddedConsts" $name}}
+	{{endPattern "embeddedConsts" $name}}
+)
+`))
+
+var metadataTypeTpl = template.Must(embeddedVarTpl.New("metadataType").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+// {{$name}}Metadata metadata of the embedded file.
+type {{$name}}Metadata struct {
+	Size    int64       // size of the file in bytes
+	Mode    os.FileMode // mode of the file
+	ModTime time.Time   // modification time of the file
+	SHA256  string      // hex encoded SHA-256 digest of the content
+}
+`))
+
+var _ = template.Must(embeddedVarTpl.New("metadata").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+{{- template "metadataType" . }}
+
+var {{$private}}Metadata = map[string]{{$name}}Metadata{
+	{{startPattern "embeddedMetadata" $name}}
+	{{endPattern "embeddedMetadata" $name}}
+}
+
+// {{$name}}Info returns the metadata of the embedded file.
+func {{$name}}Info(name string) ({{$name}}Metadata, bool) {
+	md, ok := {{$private}}Metadata[name]
+	return md, ok
+}
+`))
+
+var _ = template.Must(embeddedVarTpl.New("compress").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+var (
+	{{$private}}Mu    sync.Mutex
+	{{$private}}Cache = map[string][]byte{}
+)
+
+// {{$name}}Get returns the decompressed content of the embedded file.
+// The content is decompressed on first access and cached.
+func {{$name}}Get(name string) ([]byte, error) {
+	{{$private}}Mu.Lock()
+	defer {{$private}}Mu.Unlock()
+
+	if dat, ok := {{$private}}Cache[name]; ok {
+		return {{ if .Immutable }}{{$private}}Copy(dat){{ else }}dat{{ end }}, nil
+	}
+
+	compressed, ok := {{$map}}[name]
+	if !ok {
+		{{- if .Accessors }}
+		return nil, &{{$name}}NotFoundError{Name: name}
+		{{- else }}
+		return nil, errors.New("not found embedded file " + name)
+		{{- end }}
+	}
+	{{- if eq .Compress "flate" }}
+	r := flate.NewReader(bytes.NewReader(compressed))
+	{{- else }}
+	r, err := {{.Compress}}.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	{{- end }}
+	defer r.Close()
+
+	dat, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	{{$private}}Cache[name] = dat
+	return {{ if .Immutable }}{{$private}}Copy(dat){{ else }}dat{{ end }}, nil
+}
+
+// {{$name}}Compressed returns the compressed content of the embedded file.
+func {{$name}}Compressed(name string) ([]byte, bool) {
+	dat, ok := {{$map}}[name]
+	return {{ if .Immutable }}{{$private}}Copy(dat){{ else }}dat{{ end }}, ok
+}
+`))
+
+// accessorsTpl is the template of the accessor functions, <Var>Get is generated by the compress template
+// if the files are compressed.
+var accessorsTpl = template.Must(embeddedVarTpl.New("accessors").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+// {{$name}}NotFoundError is returned by the accessors of {{$name}} if the file is not embedded.
+type {{$name}}NotFoundError struct {
+	Name string
+}
+
+func (e *{{$name}}NotFoundError) Error() string {
+	return "not found embedded file " + e.Name
+}
+
+// Is reports whether the target is os.ErrNotExist.
+func (e *{{$name}}NotFoundError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+{{- if not .Compress }}
+
+// {{$name}}Get returns the content of the embedded file.
+func {{$name}}Get(name string) ([]byte, error) {
+	{{- if .DevFile }}
+	if _, ok := {{$private}}Paths[name]; !ok {
+		return nil, &{{$name}}NotFoundError{Name: name}
+	}
+	return {{$private}}Read(name)
+	{{- else }}
+	dat, ok := {{$map}}[name]
+	if !ok {
+		return nil, &{{$name}}NotFoundError{Name: name}
+	}
+	return {{ if .Immutable }}{{$private}}Copy(dat){{ else }}dat{{ end }}, nil
+	{{- end }}
+}
+{{- end }}
+{{- if and .Immutable (not .DevFile) }}
+
+// {{$private}}Copy returns the copy of the content, so the callers can not modify the embedded files.
+func {{$private}}Copy(dat []byte) []byte {
+	return append(make([]byte, 0, len(dat)), dat...)
+}
+{{- end }}
+
+// {{$name}}MustGet returns the content of the embedded file, panics if the file is not embedded.
+func {{$name}}MustGet(name string) []byte {
+	dat, err := {{$name}}Get(name)
+	if err != nil {
+		panic("genembed: " + err.Error())
+	}
+	return dat
+}
+
+// {{$name}}Names returns the sorted names of the embedded files.
+func {{$name}}Names() []string {
+	names := make([]string, 0, len({{$map}}))
+	for name := range {{$map}} {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// {{$name}}Walk calls fn for the embedded files with the names starting with the prefix in the sorted order.
+// The walk stops on the first error, the error is returned.
+func {{$name}}Walk(prefix string, fn func(name string, dat []byte) error) error {
+	for _, name := range {{$name}}Names() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		dat, err := {{$name}}Get(name)
+		if err != nil {
+			return err
+		}
+		if err := fn(name, dat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// {{$name}}Open returns the reader of the content of the embedded file.
+func {{$name}}Open(name string) (io.ReadSeeker, error) {
+	{{- if and .Immutable (not .Compress) (not .DevFile) }}
+	// NOTE: the reader does not modify the content, so the content is not copied
+	dat, ok := {{$map}}[name]
+	if !ok {
+		return nil, &{{$name}}NotFoundError{Name: name}
+	}
+	{{- else }}
+	dat, err := {{$name}}Get(name)
+	if err != nil {
+		return nil, err
+	}
+	{{- end }}
+	return bytes.NewReader(dat), nil
+}
+`))
+
+var fsTpl = template.Must(embeddedVarTpl.New("fs").Parse(`
+{{- $name := .FieldName }}
+{{- $map := .MapName }}
+{{- $private := unexported .FieldName }}
+// {{$name}}FS returns the file system with the embedded files.
+// The directories are built from the names of the files, the leading slash of the names is ignored.
+func {{$name}}FS() http.FileSystem {
+	return {{$private}}FS{}
+}
+
+type {{$private}}FS struct{}
+
+// Open implements http.FileSystem.
+func (fs {{$private}}FS) Open(name string) (http.File, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if key, ok := fs.key(name); ok {
+		dat, err := fs.content(key)
+		if err != nil {
+			return nil, err
+		}
+		return &{{$private}}File{
+			Reader: bytes.NewReader(dat),
+			info:   {{$private}}FileInfo{name: path.Base(name), size: int64(len(dat))
+				{{- if and .Metadata (not .DevFile) }}, mode: {{$private}}Metadata[key].Mode, modTime: {{$private}}Metadata[key].ModTime{{ end }}},
+		}, nil
+	}
+
+	prefix := name + "/"
+	if name == "" {
+		prefix = ""
+	}
+	var files []os.FileInfo
+	dirs := map[string]bool{}
+	for key := range {{$map}} {
+		rel := strings.TrimPrefix(key, "/")
+		if !strings.HasPrefix(rel, prefix) {
+			continue
+		}
+		child := strings.TrimPrefix(rel, prefix)
+		if i := strings.Index(child, "/"); i >= 0 {
+			if !dirs[child[:i]] {
+				dirs[child[:i]] = true
+				files = append(files, {{$private}}FileInfo{name: child[:i], dir: true})
+			}
+			continue
+		}
+		dat, err := fs.content(key)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, {{$private}}FileInfo{name: child, size: int64(len(dat))
+			{{- if and .Metadata (not .DevFile) }}, mode: {{$private}}Metadata[key].Mode, modTime: {{$private}}Metadata[key].ModTime{{ end }}})
+	}
+	if len(files) == 0 && name != "" {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	return &{{$private}}File{
+		Reader: bytes.NewReader(nil),
+		info:   {{$private}}FileInfo{name: path.Base("/" + name), dir: true},
+		files:  files,
+	}, nil
+}
+
+// key returns the key of the embedded file by the name without the leading slash.
+func ({{$private}}FS) key(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	if _, ok := {{$map}}[name]; ok {
+		return name, true
+	}
+	if _, ok := {{$map}}["/"+name]; ok {
+		return "/" + name, true
+	}
+	return "", false
+}
+
+// content returns the content of the embedded file.
+func ({{$private}}FS) content(name string) ([]byte, error) {
+	{{- if .DevFile }}
+	return {{$private}}Read(name)
+	{{- else if .Compress }}
+	return {{$name}}Get(name)
+	{{- else }}
+	return {{$map}}[name], nil
+	{{- end }}
+}
+
+// {{$private}}File implements http.File.
+type {{$private}}File struct {
+	*bytes.Reader
+	info  {{$private}}FileInfo
+	files []os.FileInfo // not read entries of the directory
+}
+
+// Close implements http.File.
+func (f *{{$private}}File) Close() error {
+	return nil
+}
+
+// Readdir implements http.File.
+func (f *{{$private}}File) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.info.dir {
+		return nil, &os.PathError{Op: "readdir", Path: f.info.name, Err: errors.New("not a directory")}
+	}
+	if count <= 0 {
+		files := f.files
+		f.files = nil
+		return files, nil
+	}
+	if len(f.files) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.files) {
+		count = len(f.files)
+	}
+	files := f.files[:count]
+	f.files = f.files[count:]
+	return files, nil
+}
+
+// Stat implements http.File.
+func (f *{{$private}}File) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+// {{$private}}FileInfo implements os.FileInfo.
+type {{$private}}FileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode // zero means the default mode
+	modTime time.Time
+	dir     bool
+}
+
+func (fi {{$private}}FileInfo) Name() string       { return fi.name }
+func (fi {{$private}}FileInfo) Size() int64        { return fi.size }
+func (fi {{$private}}FileInfo) ModTime() time.Time { return fi.modTime }
+func (fi {{$private}}FileInfo) IsDir() bool        { return fi.dir }
+func (fi {{$private}}FileInfo) Sys() interface{}   { return nil }
+
+func (fi {{$private}}FileInfo) Mode() os.FileMode {
+	switch {
+	case fi.dir:
+		return os.ModeDir | 0555
+	case fi.mode != 0:
+		return fi.mode
+	}
+	return 0444
+}
+`))
+
+// MapName returns the name of the map variable with the embedded files.
+func (cfg embeddedFileConfig) MapName() string {
+	return Options{Var: cfg.FieldName, Immutable: cfg.Immutable}.MapName()
+}
+
+// startPattern returns the pattern after which the entries of the section of the variable are placed.
+func startPattern(section, fieldName string) string {
+	return "// [START " + section + " " + fieldName + "]"
+}
+
+// endPattern returns the pattern before which the entries of the section of the variable are placed.
+func endPattern(section, fieldName string) string {
+	return "// [END " + section + " " + fieldName + "]"
+}
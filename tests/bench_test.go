@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// BenchmarkEncoding compares the encodings of the embedded files by
+// the time of generation, the size of the generated file and the time of build.
+func BenchmarkEncoding(b *testing.B) {
+	buildGenembed(b)
+
+	bin, err := filepath.Abs("../bin/genembed")
+	require.NoError(b, err)
+
+	// text asset (like js or json)
+	var asset bytes.Buffer
+	for i := 0; asset.Len() < 512*1024; i++ {
+		fmt.Fprintf(&asset, "function f%d(a, b) { return \"%d\" + a + b; }\n", i, i)
+	}
+
+	for _, encoding := range []string{"bytes", "string"} {
+		b.Run(encoding, func(b *testing.B) {
+			dir, err := ioutil.TempDir("", "genembed")
+			require.NoError(b, err, "failed create temporary dir")
+
+			defer os.RemoveAll(dir)
+
+			writeFile(b, dir, "asset.js", asset.String())
+			genFile := filepath.Join(dir, "main_genembed.go")
+
+			generate := func(b *testing.B) {
+				os.Remove(genFile)
+				out, err := runBin(dir, bin, "-pkg", "main", "-encoding", encoding, "EmbedFiles", "asset.js")
+				require.NoError(b, err, "failed generate, out=%s", out)
+			}
+
+			b.Run("generate", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					generate(b)
+				}
+
+				info, err := os.Stat(genFile)
+				require.NoError(b, err)
+				b.ReportMetric(float64(info.Size()), "src-bytes")
+			})
+
+			b.Run("build", func(b *testing.B) {
+				generate(b)
+
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					// NOTE: the package is changed on each iteration to avoid the build cache
+					writeFiles(b, dir, []fileConfig{
+						{"main.go", "main", fmt.Sprintf("const iteration = %d", i), map[string]string{"EmbedFiles": "asset.js"}},
+					})
+					b.StartTimer()
+
+					out, err := runBin(dir, "go", "build", "-o", os.DevNull, ".")
+					require.NoError(b, err, "failed build, out=%s", out)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkGenerateSize measures the generation of the files of different sizes,
+// the throughput (MB/s) does not depend on the size as the generation is linear.
+func BenchmarkGenerateSize(b *testing.B) {
+	buildGenembed(b)
+
+	bin, err := filepath.Abs("../bin/genembed")
+	require.NoError(b, err)
+
+	for _, size := range []int{1 << 20, 2 << 20, 4 << 20, 8 << 20} {
+		asset := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(asset)
+
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			dir, err := ioutil.TempDir("", "genembed")
+			require.NoError(b, err, "failed create temporary dir")
+
+			defer os.RemoveAll(dir)
+
+			writeFile(b, dir, "asset.bin", string(asset))
+			genFile := filepath.Join(dir, "main_genembed.go")
+
+			generate := func(b *testing.B) {
+				out, err := runBin(dir, bin, "-pkg", "main", "EmbedFiles", "asset.bin")
+				require.NoError(b, err, "failed generate, out=%s", out)
+			}
+
+			b.Run("new", func(b *testing.B) {
+				b.SetBytes(int64(size))
+				for i := 0; i < b.N; i++ {
+					os.Remove(genFile)
+					generate(b)
+				}
+			})
+
+			b.Run("regenerate", func(b *testing.B) {
+				generate(b)
+
+				b.SetBytes(int64(size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					generate(b)
+				}
+			})
+		})
+	}
+}